@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"stockterm/internal/api"
+	"stockterm/internal/cache"
+	"stockterm/internal/config"
+	"stockterm/internal/ui"
+)
+
+// newFlagSet creates a flag set for a command. Errors are returned to the caller rather than printed.
+func newFlagSet(command string) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// parseFlags parses the flags in args and returns the remaining positional
+// arguments. Unlike flag.FlagSet.Parse, flags may follow positional arguments.
+// Everything after a "--" terminator is positional, even if it looks like a flag.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		// Parse stops after consuming a terminator, leaving only positional arguments
+		rest := flags.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		args = rest
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// registerList adds the --list flag and its -w shorthand, which select a named watchlist, to a flag set
+func registerList(flags *flag.FlagSet, list *string) {
+	flags.StringVar(list, "list", "", "")
+	flags.StringVar(list, "w", "", "")
+}
+
+// stringList is a flag that can be given several times, collecting every value
+type stringList []string
+
+// String implements flag.Value
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// fetchOptions holds the flags shared by the commands that fetch quotes
+type fetchOptions struct {
+	timeRange string
+	interval  string
+	noCache   bool
+	offline   bool
+}
+
+// register adds the fetch flags to a flag set
+func (o *fetchOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.timeRange, "range", "", "")
+	flags.StringVar(&o.interval, "interval", "", "")
+	flags.BoolVar(&o.noCache, "no-cache", false, "")
+	flags.BoolVar(&o.offline, "offline", false, "")
+}
+
+// resolve fills in the time range and interval from the configuration if they
+// weren't given as flags, and checks that the provider supports them
+func (o *fetchOptions) resolve(cfg *config.Config, capabilities api.Capabilities) error {
+	if o.timeRange == "" {
+		o.timeRange = cfg.DefaultTimeRange
+	}
+	if o.interval == "" {
+		o.interval = cfg.DefaultInterval
+	}
+
+	if !capabilities.SupportsTimeRange(o.timeRange) {
+		return fmt.Errorf("invalid range: '%s', expected one of %s", o.timeRange, strings.Join(capabilities.TimeRanges, ", "))
+	}
+	if o.interval != "" && !capabilities.SupportsInterval(o.interval) {
+		return fmt.Errorf("invalid interval: '%s', expected one of %s", o.interval, strings.Join(capabilities.Intervals, ", "))
+	}
+
+	return nil
+}
+
+// title returns the table title describing the time range and interval
+func (o *fetchOptions) title() string {
+	if o.interval == "" {
+		return "Range: " + o.timeRange
+	}
+	return fmt.Sprintf("Range: %s, Interval: %s", o.timeRange, o.interval)
+}
+
+// provider returns the provider to fetch quotes with, according to the options
+func (o *fetchOptions) provider(provider api.Provider) api.Provider {
+	cached, ok := provider.(*cache.Provider)
+	if !ok {
+		return provider
+	}
+
+	switch {
+	case o.offline:
+		return cached.Offline()
+	case o.noCache:
+		return cached.Refreshing()
+	default:
+		return provider
+	}
+}
+
+// Output formats of the get and get-all commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// tableOptions holds the flags that control how stock tables are shown
+type tableOptions struct {
+	columns string
+	sort    string
+	top     int
+	output  string
+}
+
+// register adds the table flags to a flag set
+func (o *tableOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.columns, "columns", "", "")
+	flags.StringVar(&o.sort, "sort", "", "")
+	flags.IntVar(&o.top, "top", 0, "")
+	flags.StringVar(&o.output, "output", outputTable, "")
+}
+
+// apply configures the table renderer according to the options, falling back
+// to the configuration for options that weren't given as flags
+func (o *tableOptions) apply(cfg *config.Config, tableRenderer *ui.TableRenderer) error {
+	if o.output != outputTable && o.output != outputJSON {
+		return fmt.Errorf("invalid output: '%s', expected '%s' or '%s'", o.output, outputTable, outputJSON)
+	}
+
+	specs := cfg.Table.Columns
+	if o.columns != "" {
+		specs = strings.Split(o.columns, ",")
+	}
+
+	columns, err := ui.ParseColumns(specs)
+	if err != nil {
+		return err
+	}
+	tableRenderer.WithColumns(columns)
+
+	if o.sort != "" {
+		sort, err := ui.ParseSort(o.sort)
+		if err != nil {
+			return err
+		}
+		tableRenderer.WithSort(sort)
+	}
+
+	if o.top < 0 {
+		return fmt.Errorf("invalid top: %d, expected a positive number", o.top)
+	}
+	tableRenderer.WithTop(o.top)
+
+	return nil
+}
+
+// render shows fetch results in the output format, as a table titled after
+// the fetch options or as a JSON document
+func (o *tableOptions) render(tableRenderer *ui.TableRenderer, opts fetchOptions, results []api.Result) error {
+	if o.output == outputJSON {
+		if err := tableRenderer.RenderJSON(results, opts.timeRange, opts.interval); err != nil {
+			return fmt.Errorf("error writing JSON: %w", err)
+		}
+		return nil
+	}
+
+	tableRenderer.WithTitle(opts.title()).RenderResults(results)
+	return nil
+}
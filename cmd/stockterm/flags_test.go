@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		list       string
+		top        int
+	}{
+		{"no arguments", nil, nil, "", 0},
+		{"flags first", []string{"--list", "tech", "AAPL"}, []string{"AAPL"}, "tech", 0},
+		{"flags last", []string{"AAPL", "MSFT", "-w", "tech", "--top=3"}, []string{"AAPL", "MSFT"}, "tech", 3},
+		{"flags between", []string{"AAPL", "--top", "2", "MSFT"}, []string{"AAPL", "MSFT"}, "", 2},
+		{"terminator", []string{"--top", "2", "--", "-AAPL", "--list"}, []string{"-AAPL", "--list"}, "", 2},
+		{"terminator after positional", []string{"AAPL", "--", "--top", "5"}, []string{"AAPL", "--top", "5"}, "", 0},
+		{"terminator alone", []string{"--"}, nil, "", 0},
+		{"second terminator is positional", []string{"--", "--", "AAPL"}, []string{"--", "AAPL"}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := newFlagSet("test")
+			var list string
+			registerList(flags, &list)
+			top := flags.Int("top", 0, "")
+
+			positional, err := parseFlags(flags, tt.args)
+			if err != nil {
+				t.Fatalf("parseFlags: %v", err)
+			}
+			if !reflect.DeepEqual(positional, tt.positional) {
+				t.Errorf("positional = %q, want %q", positional, tt.positional)
+			}
+			if list != tt.list || *top != tt.top {
+				t.Errorf("list, top = %q, %d, want %q, %d", list, *top, tt.list, tt.top)
+			}
+		})
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"AAPL", "--unknown"},
+		{"--top", "many"},
+		{"AAPL", "--list"},
+	} {
+		flags := newFlagSet("test")
+		var list string
+		registerList(flags, &list)
+		flags.Int("top", 0, "")
+
+		if _, err := parseFlags(flags, args); err == nil {
+			t.Errorf("parseFlags(%q) succeeded, want an error", args)
+		}
+	}
+}
@@ -2,15 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log"
+	"net/url"
 	"os"
+	"os/exec"
 	"os/signal"
+	"runtime"
 	"strings"
 	"syscall"
 	"time"
 
 	"stockterm/internal/api"
+	"stockterm/internal/cache"
 	"stockterm/internal/config"
+	"stockterm/internal/model"
+	"stockterm/internal/symbol"
 	"stockterm/internal/ui"
 	"stockterm/internal/watchlist"
 )
@@ -20,67 +30,173 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	// Initialize configuration
-	cfg := config.DefaultConfig()
+	// Parse global flags, which precede the command
+	flags := newFlagSet("stockterm")
+	debug := flags.Bool("debug", false, "")
+	var overrides config.Overrides
+	flags.StringVar(&overrides.ConfigPath, "config", "", "")
+	flags.StringVar(&overrides.WatchlistPath, "watchlist", "", "")
+	flags.StringVar(&overrides.DataDir, "data-dir", "", "")
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage()
+			return
+		}
+		fmt.Printf("%v\n\n%s\n", err, getUsageText())
+		os.Exit(1)
+	}
+
+	if flags.NArg() < 1 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	command := flags.Arg(0)
+	args := flags.Args()[1:]
+
+	// Send debug output to stderr if requested
+	debugLogger := log.New(io.Discard, "", 0)
+	if *debug || os.Getenv("STOCKTERM_DEBUG") != "" {
+		debugLogger = log.New(os.Stderr, "debug: ", log.Ltime|log.Lmicroseconds)
+	}
+
+	// Initialize configuration, applying environment variables and flags
+	cfg, warnings, err := config.Resolve(overrides, os.LookupEnv)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if err != nil {
+		// Let the config command run, so that the file can be fixed
+		if command != "config" {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 
 	// Migrate from legacy config if needed
 	if err := cfg.MigrateFromLegacy("./ggs.config"); err != nil {
-		fmt.Printf("Warning: Failed to migrate from legacy config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: Failed to migrate from legacy config: %v\n", err)
 	}
 
 	// Initialize services
-	yahooClient := api.NewYahooFinanceClient()
+	clientOptions, err := newClientOptions(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	clientOptions = append(clientOptions, api.WithLogger(debugLogger))
+
+	// Record or replay HTTP fixtures, for development and testing
+	if dir := os.Getenv("STOCKTERM_HTTP_RECORD"); dir != "" {
+		clientOptions = append(clientOptions, api.WithTransport(api.NewFixtureTransport(dir, api.FixtureRecord)))
+	} else if dir := os.Getenv("STOCKTERM_HTTP_REPLAY"); dir != "" {
+		clientOptions = append(clientOptions, api.WithTransport(api.NewFixtureTransport(dir, api.FixtureReplay)))
+	}
+
+	var provider api.Provider = api.NewYahooFinanceClient(clientOptions...)
+	var quoteCache *cache.Store
+	if cfg.Cache.Enabled {
+		quoteCache = cache.NewStore(cfg.CacheDir)
+	}
+	provider = cache.NewProvider(provider, quoteCache, cache.TTLs(cfg.Cache.TTLs)).
+		WithLastKnown(cache.NewStore(cfg.LastKnownDir))
 	watchlistService := watchlist.NewService(cfg)
 	tableRenderer := ui.NewTableRenderer()
 
-	// Parse command-line arguments
-	if len(os.Args) < 2 {
-		printUsage()
+	// Execute the command
+	if err := executeCommand(ctx, command, args, cfg, provider, watchlistService, tableRenderer); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+}
 
-	command := os.Args[1]
-	args := os.Args[2:]
+// newClientOptions returns the Yahoo Finance client options for the configuration
+func newClientOptions(cfg *config.Config) ([]api.Option, error) {
+	opts := []api.Option{
+		api.WithBaseURL(cfg.HTTP.Endpoint),
+		api.WithUserAgent(cfg.HTTP.UserAgent),
+		api.WithHeaders(cfg.HTTP.Headers),
+		api.WithTimeout(cfg.HTTP.Timeout),
+		api.WithConcurrency(cfg.MaxConcurrency),
+		api.WithRetryPolicy(api.RetryPolicy{
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			BaseDelay:   cfg.Retry.BaseDelay,
+			MaxDelay:    cfg.Retry.MaxDelay,
+		}),
+		api.WithRateLimit(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst),
+	}
 
-	// Execute the command
-	if err := executeCommand(ctx, command, args, yahooClient, watchlistService, tableRenderer); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if cfg.HTTP.Proxy != "" {
+		proxy, err := url.Parse(cfg.HTTP.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		opts = append(opts, api.WithProxy(proxy))
+	}
+
+	if cfg.HTTP.CABundle != "" {
+		pool, err := api.LoadCABundle(cfg.HTTP.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, api.WithRootCAs(pool))
 	}
+
+	return opts, nil
 }
 
 func executeCommand(
 	ctx context.Context,
 	command string,
 	args []string,
-	yahooClient *api.YahooFinanceClient,
+	cfg *config.Config,
+	provider api.Provider,
 	watchlistService *watchlist.Service,
 	tableRenderer *ui.TableRenderer,
 ) error {
 	switch command {
 	case "get":
-		if len(args) < 1 {
-			return fmt.Errorf("missing ticker argument")
-		}
-		return getTickersPrice(ctx, args[0], yahooClient, tableRenderer)
+		return getTickersPrice(ctx, args, cfg, provider, tableRenderer)
 
 	case "get-all":
-		return getWatchlistPrice(ctx, yahooClient, watchlistService, tableRenderer)
+		return getWatchlistPrice(ctx, args, cfg, provider, watchlistService, tableRenderer)
 
 	case "list":
-		return displayWatchlist(watchlistService)
+		return displayWatchlist(args, watchlistService)
 
 	case "add":
+		return addTickersToWatchlist(ctx, args, provider, watchlistService)
+
+	case "remove":
+		return removeTickersFromWatchlist(args, watchlistService)
+
+	case "search":
+		return searchTickers(ctx, args, provider, watchlistService, tableRenderer)
+
+	case "watchlist":
 		if len(args) < 1 {
-			return fmt.Errorf("missing ticker argument")
+			return fmt.Errorf("missing watchlist subcommand: expected 'create', 'delete', 'rename', 'use', 'order' or 'ls'")
 		}
-		return addTickersToWatchlist(args[0], watchlistService)
+		return manageWatchlists(args[0], args[1:], watchlistService)
 
-	case "remove":
+	case "tag":
+		if len(args) < 1 {
+			return fmt.Errorf("missing tag subcommand: expected 'add', 'rm' or 'ls'")
+		}
+		return manageTags(args[0], args[1:], watchlistService)
+
+	case "cache":
 		if len(args) < 1 {
-			return fmt.Errorf("missing ticker argument")
+			return fmt.Errorf("missing cache subcommand: expected 'clear' or 'stats'")
 		}
-		return removeTickersFromWatchlist(args[0], watchlistService)
+		return manageCache(args[0], cache.NewStore(cfg.CacheDir), cache.TTLs(cfg.Cache.TTLs))
+
+	case "config":
+		if len(args) < 1 {
+			return fmt.Errorf("missing config subcommand: expected 'get', 'set', 'edit' or 'path'")
+		}
+		return manageConfig(args[0], args[1:], cfg)
 
 	case "help":
 		printUsage()
@@ -95,34 +211,114 @@ func executeCommand(
 	}
 }
 
-func getTickersPrice(ctx context.Context, tickersArg string, yahooClient *api.YahooFinanceClient, tableRenderer *ui.TableRenderer) error {
-	// Split the tickers by comma
-	tickers := strings.Split(tickersArg, ",")
+func getTickersPrice(ctx context.Context, args []string, cfg *config.Config, provider api.Provider, tableRenderer *ui.TableRenderer) error {
+	// Parse the flags
+	var opts fetchOptions
+	var tableOpts tableOptions
+	flags := newFlagSet("get")
+	opts.register(flags)
+	tableOpts.register(flags)
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := tableOpts.apply(cfg, tableRenderer); err != nil {
+		return err
+	}
+
+	if len(args) < 1 {
+		return fmt.Errorf("missing ticker argument")
+	}
+
+	if err := opts.resolve(cfg, provider.Capabilities()); err != nil {
+		return err
+	}
+	provider = opts.provider(provider)
+
+	// Split the tickers by comma, writing them the way the provider does
+	var tickers []string
+	for _, ticker := range strings.Split(args[0], ",") {
+		if strings.TrimSpace(ticker) == "" {
+			continue
+		}
+		normalized, err := symbol.Normalize(ticker)
+		if err != nil {
+			return err
+		}
+		tickers = append(tickers, normalized)
+	}
+	if len(tickers) == 0 {
+		return fmt.Errorf("missing ticker argument")
+	}
 
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// Fetch the stock data
-	responses, err := yahooClient.FetchMultipleStocks(ctx, tickers, "1d")
+	// Fetch the stock data, and the average volumes that aren't part of it
+	results, err := provider.FetchMultipleStocks(ctx, tickers, opts.timeRange, opts.interval)
+	tableRenderer.WithAverageVolumes(averageVolumes(ctx, provider, opts, tableOpts, tableRenderer, results))
+
+	// Render the results, including a row for each ticker that failed
+	if renderErr := tableOpts.render(tableRenderer, opts, results); renderErr != nil {
+		return renderErr
+	}
+
 	if err != nil {
 		return fmt.Errorf("error fetching stock data: %w", err)
 	}
 
-	// Render the table
-	tableRenderer.RenderChartResponses(responses)
-
-	return nil
+	return fetchFailures(results)
 }
 
-func getWatchlistPrice(ctx context.Context, yahooClient *api.YahooFinanceClient, watchlistService *watchlist.Service, tableRenderer *ui.TableRenderer) error {
-	// Get the watchlist
-	watchlist, err := watchlistService.GetWatchlist()
+func getWatchlistPrice(ctx context.Context, args []string, cfg *config.Config, provider api.Provider, watchlistService *watchlist.Service, tableRenderer *ui.TableRenderer) error {
+	// Parse the flags
+	var opts fetchOptions
+	var tableOpts tableOptions
+	var list string
+	var tags stringList
+	flags := newFlagSet("get-all")
+	opts.register(flags)
+	tableOpts.register(flags)
+	registerList(flags, &list)
+	flags.Var(&tags, "tag", "")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := tableOpts.apply(cfg, tableRenderer); err != nil {
+		return err
+	}
+
+	filter, err := watchlist.ParseTagFilter(tags)
+	if err != nil {
+		return err
+	}
+
+	if err := opts.resolve(cfg, provider.Capabilities()); err != nil {
+		return err
+	}
+	provider = opts.provider(provider)
+
+	// Get the watchlist and the order to display it in
+	watchlistService.WithList(list)
+	tickers, err := watchlistService.GetTaggedWatchlist(filter)
+	if err != nil {
+		return fmt.Errorf("error getting watchlist: %w", err)
+	}
+	order, err := watchlistService.GetOrder()
 	if err != nil {
 		return fmt.Errorf("error getting watchlist: %w", err)
 	}
 
-	if len(watchlist) == 0 {
+	if len(tickers) == 0 {
+		// Scripts get an empty document rather than a message
+		if tableOpts.output == outputJSON {
+			return tableOpts.render(tableRenderer, opts, nil)
+		}
+		if !filter.IsEmpty() {
+			fmt.Println("No tickers in the watchlist match the tags")
+			return nil
+		}
 		fmt.Println("Watchlist is empty. Add tickers with 'stockterm add <ticker>'")
 		return nil
 	}
@@ -131,21 +327,90 @@ func getWatchlistPrice(ctx context.Context, yahooClient *api.YahooFinanceClient,
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// Fetch the stock data
-	responses, err := yahooClient.FetchMultipleStocks(ctx, watchlist, "1d")
+	// Fetch the stock data, and the average volumes that aren't part of it
+	results, err := provider.FetchMultipleStocks(ctx, tickers, opts.timeRange, opts.interval)
+	tableRenderer.WithAverageVolumes(averageVolumes(ctx, provider, opts, tableOpts, tableRenderer, results))
+
+	// Sort the results if the order depends on quotes
+	watchlist.OrderResults(order, results)
+
+	// Render the results, including a row for each ticker that failed
+	if renderErr := tableOpts.render(tableRenderer, opts, results); renderErr != nil {
+		return renderErr
+	}
+
 	if err != nil {
 		return fmt.Errorf("error fetching stock data: %w", err)
 	}
 
-	// Render the table
-	tableRenderer.RenderChartResponses(responses)
+	return fetchFailures(results)
+}
+
+// averageVolumes returns the average daily volume of the tickers that were
+// fetched, by ticker, if the output includes it. It is taken from a 3mo chart
+// at a 1d interval, which is fetched unless that is what was fetched already.
+// Tickers whose chart can't be fetched are left out, and shown as unknown.
+func averageVolumes(ctx context.Context, provider api.Provider, opts fetchOptions, tableOpts tableOptions, tableRenderer *ui.TableRenderer, results []api.Result) map[string]int64 {
+	if tableOpts.output != outputJSON && !tableRenderer.Uses("avg_volume") {
+		return nil
+	}
+
+	var daily []api.Result
+	var tickers []string
+	for _, result := range results {
+		if result.Err == nil {
+			daily = append(daily, result)
+			tickers = append(tickers, result.Ticker)
+		}
+	}
+	if len(tickers) == 0 {
+		return nil
+	}
+
+	if opts.timeRange != "3mo" || (opts.interval != "" && opts.interval != "1d") {
+		daily, _ = provider.FetchMultipleStocks(ctx, tickers, "3mo", "1d")
+	}
+
+	volumes := make(map[string]int64, len(daily))
+	for _, result := range daily {
+		if result.Err != nil {
+			continue
+		}
+		if volume := model.AverageVolume(result.Response); volume > 0 {
+			volumes[result.Ticker] = volume
+		}
+	}
+	return volumes
+}
+
+// fetchFailures returns an error summarizing how many results failed, or nil if none did
+func fetchFailures(results []api.Result) error {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to fetch %d of %d tickers", failed, len(results))
+	}
 
 	return nil
 }
 
-func displayWatchlist(watchlistService *watchlist.Service) error {
-	// Get the watchlist
-	watchlist, err := watchlistService.GetWatchlist()
+func displayWatchlist(args []string, watchlistService *watchlist.Service) error {
+	// Parse the flags
+	var list string
+	flags := newFlagSet("list")
+	registerList(flags, &list)
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	watchlistService.WithList(list)
+
+	// Get the watchlist, in the order it is curated in
+	watchlist, err := watchlistService.GetManualOrder()
 	if err != nil {
 		return fmt.Errorf("error getting watchlist: %w", err)
 	}
@@ -156,7 +421,10 @@ func displayWatchlist(watchlistService *watchlist.Service) error {
 	}
 
 	// Run the watchlist editor
-	updatedWatchlist, saved := ui.RunWatchlistEditor(watchlist)
+	updatedWatchlist, saved, err := ui.RunWatchlistEditor(watchlist)
+	if err != nil {
+		return err
+	}
 	if !saved {
 		fmt.Println("Watchlist not updated")
 		return nil
@@ -171,9 +439,34 @@ func displayWatchlist(watchlistService *watchlist.Service) error {
 	return nil
 }
 
-func addTickersToWatchlist(tickersArg string, watchlistService *watchlist.Service) error {
+func addTickersToWatchlist(ctx context.Context, args []string, provider api.Provider, watchlistService *watchlist.Service) error {
+	// Parse the flags
+	var list string
+	var noVerify bool
+	var entry config.WatchlistEntry
+	flags := newFlagSet("add")
+	registerList(flags, &list)
+	flags.BoolVar(&noVerify, "no-verify", false, "")
+	flags.StringVar(&entry.Notes, "note", "", "")
+	flags.Float64Var(&entry.TargetPrice, "target", 0, "")
+	flags.Float64Var(&entry.StopPrice, "stop", 0, "")
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	watchlistService.WithList(list)
+
+	if len(args) < 1 {
+		return fmt.Errorf("missing ticker argument")
+	}
+
+	// Check that the watchlist exists before changing it
+	if _, err := watchlistService.GetManualOrder(); err != nil {
+		return fmt.Errorf("error getting watchlist: %w", err)
+	}
+
 	// Split the tickers by comma
-	tickers := strings.Split(tickersArg, ",")
+	tickers := strings.Split(args[0], ",")
 
 	// Add each ticker to the watchlist
 	for _, ticker := range tickers {
@@ -182,20 +475,120 @@ func addTickersToWatchlist(tickersArg string, watchlistService *watchlist.Servic
 			continue
 		}
 
-		if err := watchlistService.AddTicker(ticker); err != nil {
+		normalized, err := symbol.Normalize(ticker)
+		if err != nil {
 			fmt.Printf("Warning: %v\n", err)
 			continue
 		}
+		entry.Symbol = normalized
+		entry.Exchange = ""
+
+		// Check that the ticker exists, using the provider's symbol for it
+		if !noVerify {
+			verified, err := watchlist.Verify(ctx, provider, ticker)
+			var unknown *watchlist.UnknownTickerError
+			if errors.As(err, &unknown) {
+				fmt.Printf("Warning: %v\n", err)
+				continue
+			}
+			if err != nil {
+				fmt.Printf("Warning: could not verify %s, add it with --no-verify to skip the check: %v\n", entry.Symbol, err)
+				continue
+			}
+			entry.Symbol = verified.Symbol
+			entry.Exchange = verified.Exchange
+		}
+
+		if err := watchlistService.AddEntry(entry); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+
+		fmt.Printf("%s has been added to the watchlist\n", entry.Symbol)
+	}
+
+	return nil
+}
+
+func searchTickers(ctx context.Context, args []string, provider api.Provider, watchlistService *watchlist.Service, tableRenderer *ui.TableRenderer) error {
+	// Parse the flags
+	var list string
+	var add int
+	flags := newFlagSet("search")
+	registerList(flags, &list)
+	flags.IntVar(&add, "add", 0, "")
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	watchlistService.WithList(list)
+
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		return fmt.Errorf("missing search query")
+	}
+
+	// Create a context with timeout
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-		fmt.Printf("%s has been added to the watchlist\n", ticker)
+	results, err := provider.Search(ctx, query)
+	if err != nil {
+		return fmt.Errorf("error searching for tickers: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No tickers match '%s'\n", query)
+		return nil
 	}
 
+	tableRenderer.WithTitle("Search: " + query).RenderSearchResults(results)
+
+	if add == 0 {
+		return nil
+	}
+	if add < 0 || add > len(results) {
+		return fmt.Errorf("invalid result number %d: expected 1 to %d", add, len(results))
+	}
+
+	// The result comes from the provider, so it doesn't need verifying. The
+	// exchange is taken from its chart, like add does, since search results
+	// name exchanges differently; it is left out if the chart can't be fetched.
+	result := results[add-1]
+	entry := config.WatchlistEntry{Symbol: result.Symbol}
+	if verified, err := watchlist.Verify(ctx, provider, result.Symbol); err == nil {
+		entry = verified
+	}
+	if err := watchlistService.AddEntry(entry); err != nil {
+		return fmt.Errorf("error adding ticker: %w", err)
+	}
+
+	fmt.Printf("%s has been added to the watchlist\n", entry.Symbol)
 	return nil
 }
 
-func removeTickersFromWatchlist(tickersArg string, watchlistService *watchlist.Service) error {
+func removeTickersFromWatchlist(args []string, watchlistService *watchlist.Service) error {
+	// Parse the flags
+	var list string
+	flags := newFlagSet("remove")
+	registerList(flags, &list)
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	watchlistService.WithList(list)
+
+	if len(args) < 1 {
+		return fmt.Errorf("missing ticker argument")
+	}
+
+	// Check that the watchlist exists before changing it
+	if _, err := watchlistService.GetManualOrder(); err != nil {
+		return fmt.Errorf("error getting watchlist: %w", err)
+	}
+
 	// Split the tickers by comma
-	tickers := strings.Split(tickersArg, ",")
+	tickers := strings.Split(args[0], ",")
 
 	// Remove each ticker from the watchlist
 	for _, ticker := range tickers {
@@ -204,6 +597,11 @@ func removeTickersFromWatchlist(tickersArg string, watchlistService *watchlist.S
 			continue
 		}
 
+		// Tickers that can't be normalized may still be in older watchlists
+		if normalized, err := symbol.Normalize(ticker); err == nil {
+			ticker = normalized
+		}
+
 		if err := watchlistService.RemoveTicker(ticker); err != nil {
 			fmt.Printf("Warning: %v\n", err)
 			continue
@@ -215,6 +613,300 @@ func removeTickersFromWatchlist(tickersArg string, watchlistService *watchlist.S
 	return nil
 }
 
+func manageWatchlists(subcommand string, args []string, watchlistService *watchlist.Service) error {
+	switch subcommand {
+	case "ls":
+		names, current, err := watchlistService.Lists()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			marker := " "
+			if name == current {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\n", marker, name)
+		}
+		return nil
+
+	case "create":
+		if len(args) < 1 {
+			return fmt.Errorf("missing watchlist name")
+		}
+		if err := watchlistService.CreateList(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Watchlist %s has been created\n", args[0])
+		return nil
+
+	case "delete":
+		if len(args) < 1 {
+			return fmt.Errorf("missing watchlist name")
+		}
+		if err := watchlistService.DeleteList(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Watchlist %s has been deleted\n", args[0])
+		return nil
+
+	case "rename":
+		if len(args) < 2 {
+			return fmt.Errorf("missing arguments: expected 'watchlist rename <old> <new>'")
+		}
+		if err := watchlistService.RenameList(args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Watchlist %s has been renamed to %s\n", args[0], args[1])
+		return nil
+
+	case "order":
+		return orderWatchlist(args, watchlistService)
+
+	case "use":
+		if len(args) < 1 {
+			return fmt.Errorf("missing watchlist name")
+		}
+		if err := watchlistService.UseList(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Now using watchlist %s\n", args[0])
+		return nil
+
+	default:
+		return fmt.Errorf("invalid watchlist subcommand: '%s', expected 'create', 'delete', 'rename', 'use', 'order' or 'ls'", subcommand)
+	}
+}
+
+func orderWatchlist(args []string, watchlistService *watchlist.Service) error {
+	// Parse the flags
+	var list string
+	flags := newFlagSet("watchlist order")
+	registerList(flags, &list)
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	watchlistService.WithList(list)
+
+	// Without an order, print the current one
+	if len(args) < 1 {
+		order, err := watchlistService.GetOrder()
+		if err != nil {
+			return err
+		}
+		fmt.Println(order)
+		return nil
+	}
+
+	order, err := config.ParseWatchlistOrder(args[0])
+	if err != nil {
+		return err
+	}
+	if err := watchlistService.SetOrder(order); err != nil {
+		return err
+	}
+
+	fmt.Printf("Watchlist order has been set to %s\n", order)
+	return nil
+}
+
+func manageTags(subcommand string, args []string, watchlistService *watchlist.Service) error {
+	// Parse the flags
+	var list string
+	flags := newFlagSet("tag " + subcommand)
+	registerList(flags, &list)
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	watchlistService.WithList(list)
+
+	switch subcommand {
+	case "add", "rm":
+		if len(args) < 2 {
+			return fmt.Errorf("missing arguments: expected 'tag %s <ticker> <tag>...'", subcommand)
+		}
+
+		// Tag or untag each ticker
+		for _, ticker := range strings.Split(args[0], ",") {
+			ticker = strings.TrimSpace(ticker)
+			if ticker == "" {
+				continue
+			}
+
+			if subcommand == "add" {
+				err = watchlistService.AddTags(ticker, args[1:])
+			} else {
+				err = watchlistService.RemoveTags(ticker, args[1:])
+			}
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+				continue
+			}
+
+			if subcommand == "add" {
+				fmt.Printf("%s has been tagged %s\n", ticker, strings.Join(args[1:], ", "))
+			} else {
+				fmt.Printf("%s has been untagged %s\n", ticker, strings.Join(args[1:], ", "))
+			}
+		}
+		return nil
+
+	case "ls":
+		// With a ticker, print its tags
+		if len(args) > 0 {
+			tags, err := watchlistService.GetTickerTags(args[0])
+			if err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				fmt.Println(tag)
+			}
+			return nil
+		}
+
+		// Otherwise, print every tag along with its tickers
+		tags, tickers, err := watchlistService.GetTags()
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			fmt.Println("No tickers are tagged. Tag tickers with 'stockterm tag add <ticker> <tag>'")
+			return nil
+		}
+		for _, tag := range tags {
+			fmt.Printf("%s: %s\n", tag, strings.Join(tickers[tag], ", "))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid tag subcommand: '%s', expected 'add', 'rm' or 'ls'", subcommand)
+	}
+}
+
+func manageCache(subcommand string, store *cache.Store, ttls cache.TTLs) error {
+	switch subcommand {
+	case "clear":
+		removed, err := store.Clear()
+		if err != nil {
+			return fmt.Errorf("error clearing cache: %w", err)
+		}
+		fmt.Printf("Removed %d cached quotes\n", removed)
+		return nil
+
+	case "stats":
+		stats, err := store.Stats(ttls, time.Now())
+		if err != nil {
+			return fmt.Errorf("error reading cache: %w", err)
+		}
+		fmt.Printf("Location: %s\n", store.Dir())
+		fmt.Printf("Entries:  %d (%d fresh, %d expired)\n", stats.Entries, stats.Fresh, stats.Expired)
+		fmt.Printf("Size:     %.1f KB\n", float64(stats.Size)/1024)
+		if stats.Entries > 0 {
+			fmt.Printf("Oldest:   %s\n", stats.Oldest.Local().Format(time.DateTime))
+			fmt.Printf("Newest:   %s\n", stats.Newest.Local().Format(time.DateTime))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid cache subcommand: '%s', expected 'clear' or 'stats'", subcommand)
+	}
+}
+
+func manageConfig(subcommand string, args []string, cfg *config.Config) error {
+	switch subcommand {
+	case "path":
+		fmt.Println(cfg.ConfigPath)
+		return nil
+
+	case "get":
+		// Without a key, print the whole configuration
+		key := ""
+		if len(args) > 0 {
+			key = args[0]
+		}
+
+		value, err := cfg.Get(key)
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+
+	case "set":
+		if len(args) < 2 {
+			return fmt.Errorf("missing arguments: expected 'config set <key> <value>'")
+		}
+
+		// Start from the file rather than cfg, so environment overrides aren't saved
+		fileCfg := config.DefaultConfig()
+		fileCfg.ConfigPath = cfg.ConfigPath
+		if _, err := fileCfg.Load(); err != nil {
+			return err
+		}
+
+		if err := fileCfg.Set(args[0], args[1]); err != nil {
+			return err
+		}
+		if err := fileCfg.Save(); err != nil {
+			return fmt.Errorf("error saving config: %w", err)
+		}
+
+		fmt.Printf("%s has been set\n", args[0])
+		return nil
+
+	case "edit":
+		return editConfig(cfg)
+
+	default:
+		return fmt.Errorf("invalid config subcommand: '%s', expected 'get', 'set', 'edit' or 'path'", subcommand)
+	}
+}
+
+func editConfig(cfg *config.Config) error {
+	// Write the current configuration first, so there is something to edit
+	if _, err := os.Stat(cfg.ConfigPath); os.IsNotExist(err) {
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("error saving config: %w", err)
+		}
+	}
+
+	// Open the file in the user's editor
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	editorArgs := append(strings.Fields(editor), cfg.ConfigPath)
+	cmd := exec.Command(editorArgs[0], editorArgs[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running editor: %w", err)
+	}
+
+	// Check the edited file
+	edited := config.DefaultConfig()
+	edited.ConfigPath = cfg.ConfigPath
+	warnings, err := edited.Load()
+	for _, warning := range warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if err != nil {
+		return fmt.Errorf("%w\nRun 'stockterm config edit' to fix it", err)
+	}
+
+	fmt.Println("Config has been updated!")
+	return nil
+}
+
 func printUsage() {
 	fmt.Println(getUsageText())
 }
@@ -223,25 +915,104 @@ func getUsageText() string {
 	return `StockTerm - A terminal-based stock viewer
 
 Usage:
-  stockterm <command> [arguments]
+  stockterm [global flags] <command> [arguments]
+
+Global flags:
+  --debug            Print debug output, such as retries and rate limiting delays, to stderr.
+  --config <path>    Path of the configuration file.
+  --data-dir <dir>   Directory holding the watchlists and cached quotes.
+  --watchlist <path> Path of a watchlist file to use instead of the named watchlists.
 
 Commands:
   get <ticker>       Display stock price in a table. Multiple tickers can be separated by commas.
-  get-all            Display watchlist in a table.
+  get-all            Display the watchlist in use in a table.
+  cache clear        Remove all cached quotes.
+  cache stats        Display statistics about the quote cache.
+  config get [key]   Display the configuration, or the value of a single key such as http.proxy.
+  config set <k> <v> Set a configuration key.
+  config edit        Open the configuration file in $EDITOR.
+  config path        Display the path of the configuration file.
   list               Display an editable list of all tickers in the watchlist.
   add <ticker>       Add ticker to watchlist. Multiple tickers can be separated by commas.
   remove <ticker>    Remove ticker from watchlist. Multiple tickers can be separated by commas.
+  search <query>     Look up tickers by symbol or company name.
+  watchlist ls       List all watchlists, marking the one in use.
+  watchlist create <name>
+                     Create an empty watchlist.
+  watchlist delete <name>
+                     Delete a watchlist.
+  watchlist rename <old> <new>
+                     Rename a watchlist.
+  watchlist use <name>
+                     Use a watchlist when no --list flag is given.
+  tag add <ticker> <tag>...
+                     Tag tickers in the watchlist. Multiple tickers can be separated by commas.
+  tag rm <ticker> <tag>...
+                     Remove tags from tickers in the watchlist.
+  tag ls [ticker]    Display all tags and their tickers, or the tags of a single ticker.
+  watchlist order [<order>]
+                     Display or set the order of the watchlist: manual, alphabetical
+                     or change-percent.
   help               Display this help message.
   version            Display version information.
 
+Flags for get-all, list, add, remove, search, tag and watchlist order:
+  -w, --list <name>  Use the named watchlist instead of the one in use.
+
+Flags for get-all:
+  --tag <tag>        Only show tickers with the tag, or without it if the tag starts with '-'.
+                     Can be given several times; tickers must pass every filter.
+
+Flags for add:
+  --note <text>      Notes about the tickers.
+  --target <price>   Price the tickers are expected to reach.
+  --stop <price>     Price at which to sell the tickers.
+  --no-verify        Add the tickers without checking that they exist.
+
+Flags for search:
+  --add <n>          Add the nth result to the watchlist.
+
+Flags for get and get-all:
+  --range <range>    Time range of the data, e.g. 1d, 5d, 1mo, 1y or max. Defaults to the configured range.
+  --interval <int>   Data interval, e.g. 2m, 1h or 1d. Defaults to an interval suited to the range.
+  --no-cache         Fetch fresh quotes instead of using cached ones.
+  --offline          Don't use the network; show cached or last-known quotes instead.
+  --columns <list>   Columns to show, in order, separated by commas. Each column may be
+                     followed by a number of decimals or 'short' for abbreviated numbers,
+                     as in price:4 or volume:short. Columns: ticker, price, change,
+                     change_percent, open, day_low, day_high, day_range, 52w_low, 52w_high,
+                     52w_range, volume, avg_volume, prev_close and currency.
+  --sort <column>    Sort rows by a column, followed by :desc for descending order.
+  --top <n>          Only show the n tickers with the largest change in percent, up or down.
+  --output <format>  Output format: table, the default, or json.
+
 Examples:
   stockterm get MSFT
   stockterm get AAPL,GOOGL,MSFT
+  stockterm get AAPL --range 5d --interval 1h
+  stockterm get AAPL,MSFT --columns ticker,price,change_percent:1,volume:short
   stockterm add TSLA
   stockterm add AAPL,META,TSLA
+  stockterm add NVDA --target 150 --stop 95 --note "earnings 8/28"
   stockterm remove TSLA
+  stockterm search "berkshire hathaway"
+  stockterm search nvidia --add 1
   stockterm get-all
-  stockterm list`
+  stockterm get-all --no-cache
+  stockterm get-all --offline
+  stockterm get-all --sort change_percent:desc
+  stockterm get-all --top 5
+  stockterm get-all --output json
+  stockterm watchlist create semis
+  stockterm add NVDA,AMD -w semis
+  stockterm get-all --list semis
+  stockterm watchlist order change-percent
+  stockterm tag add NVDA,AMD semis
+  stockterm get-all --tag semis --tag -spac
+  stockterm list
+  stockterm cache stats
+  stockterm config set default_range 5d
+  stockterm config set http.headers.X-Team research`
 }
 
 func printVersion() {
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"stockterm/internal/api"
+	"stockterm/internal/config"
+	"stockterm/internal/model"
+	"stockterm/internal/watchlist"
+)
+
+// unknownProvider knows no tickers and records the ones it is asked for
+type unknownProvider struct {
+	api.Provider
+	asked []string
+}
+
+func (p *unknownProvider) FetchQuote(ctx context.Context, ticker string) (model.ChartResponse, error) {
+	p.asked = append(p.asked, ticker)
+	return model.ChartResponse{}, &api.FetchError{Ticker: ticker, Kind: api.ErrNotFound}
+}
+
+func (p *unknownProvider) Search(ctx context.Context, query string) ([]model.SearchResult, error) {
+	return nil, nil
+}
+
+func TestAddTickersVerification(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		asked []string
+		added []string
+	}{
+		{"verified", []string{"zzzz,brk.b"}, []string{"ZZZZ", "BRK-B"}, []string{}},
+		{"no-verify", []string{"zzzz,brk.b", "--no-verify"}, nil, []string{"ZZZZ", "BRK-B"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.SetDataDir(t.TempDir())
+			provider := &unknownProvider{}
+
+			if err := addTickersToWatchlist(context.Background(), tt.args, provider, watchlist.NewService(cfg)); err != nil {
+				t.Fatalf("addTickersToWatchlist: %v", err)
+			}
+
+			if !reflect.DeepEqual(provider.asked, tt.asked) {
+				t.Errorf("asked the provider for %q, want %q", provider.asked, tt.asked)
+			}
+			list, err := cfg.LoadWatchlist("")
+			if err != nil {
+				t.Fatalf("LoadWatchlist: %v", err)
+			}
+			if got := list.Symbols(); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("watchlist = %q, want %q", got, tt.added)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Error kinds returned by providers. Use errors.Is to check the kind of a FetchError.
+var (
+	// ErrNotFound is returned when the provider has no data for a ticker
+	ErrNotFound = errors.New("ticker not found")
+	// ErrRateLimited is returned when the provider throttles our requests
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNetwork is returned when the provider could not be reached
+	ErrNetwork = errors.New("network error")
+	// ErrDecode is returned when the provider's response could not be decoded
+	ErrDecode = errors.New("invalid response")
+	// ErrServer is returned when the provider responds with an unexpected status
+	ErrServer = errors.New("server error")
+	// ErrUnsupported is returned when the provider can't serve the requested range or interval
+	ErrUnsupported = errors.New("unsupported range or interval")
+	// ErrUnauthorized is returned when the provider refuses to serve the request without authorization
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// FetchError describes a failure to fetch data for a single ticker
+type FetchError struct {
+	// Ticker is the ticker that could not be fetched
+	Ticker string
+	// Kind is one of the Err* error kinds
+	Kind error
+	// StatusCode is the HTTP status code, if a response was received
+	StatusCode int
+	// RetryAfter is the delay requested by the server's Retry-After header, if any
+	RetryAfter time.Duration
+	// Err is the underlying error, if any
+	Err error
+}
+
+// Error implements the error interface
+func (e *FetchError) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Ticker, e.Kind)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error kind and the underlying error
+func (e *FetchError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FixtureMode selects whether a FixtureTransport records or replays responses
+type FixtureMode int
+
+const (
+	// FixtureReplay serves previously recorded responses without using the network
+	FixtureReplay FixtureMode = iota
+	// FixtureRecord performs real requests and records their responses
+	FixtureRecord
+)
+
+// Fixture is a recorded HTTP response
+type Fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// FixtureTransport is an http.RoundTripper that records responses to fixture
+// files in a directory, or replays previously recorded ones. Fixtures are
+// matched on the request method, path and query, but not the host, so
+// responses recorded against one endpoint can be replayed against another.
+type FixtureTransport struct {
+	dir  string
+	mode FixtureMode
+	next http.RoundTripper
+}
+
+// NewFixtureTransport creates a transport that records to or replays from dir.
+// Recorded requests are performed with http.DefaultTransport.
+func NewFixtureTransport(dir string, mode FixtureMode) *FixtureTransport {
+	return &FixtureTransport{
+		dir:  dir,
+		mode: mode,
+		next: http.DefaultTransport,
+	}
+}
+
+// WithTransport sets the transport used to perform requests while recording
+func (t *FixtureTransport) WithTransport(next http.RoundTripper) *FixtureTransport {
+	t.next = next
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := filepath.Join(t.dir, FixtureName(req))
+
+	if t.mode == FixtureReplay {
+		return t.replay(req, path)
+	}
+	return t.record(req, path)
+}
+
+// replay serves the fixture at path
+func (t *FixtureTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no fixture recorded for %s %s: %w", req.Method, req.URL, err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(content, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture %s: %w", path, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        fixture.Header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}, nil
+}
+
+// record performs the request and saves its response to path
+func (t *FixtureTransport) record(req *http.Request, path string) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Fixture{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       string(body),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(path, content.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	// Hand the caller a fresh copy of the body we consumed
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// FixtureName returns the name of the fixture file for a request. It is made of
+// the method and path for readability, and a hash of the path and query.
+func FixtureName(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.Path + "?" + req.URL.Query().Encode()))
+
+	name := strings.Trim(req.URL.Path, "/")
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	return fmt.Sprintf("%s_%s_%s.json", req.Method, name, hex.EncodeToString(sum[:4]))
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Option configures a YahooFinanceClient
+type Option func(*YahooFinanceClient)
+
+// WithBaseURL sets the API endpoint, e.g. a local mirror of the Yahoo Finance API
+func WithBaseURL(baseURL string) Option {
+	return func(c *YahooFinanceClient) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *YahooFinanceClient) {
+		if userAgent != "" {
+			c.userAgent = userAgent
+		}
+	}
+}
+
+// WithHeaders adds headers sent with every request
+func WithHeaders(headers map[string]string) Option {
+	return func(c *YahooFinanceClient) {
+		for key, value := range headers {
+			c.headers.Set(key, value)
+		}
+	}
+}
+
+// WithTimeout sets the timeout of a single HTTP request
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *YahooFinanceClient) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// WithProxy sends all requests through the given HTTP proxy.
+// Without it, the proxy is taken from the HTTP_PROXY and HTTPS_PROXY environment variables.
+func WithProxy(proxy *url.URL) Option {
+	return func(c *YahooFinanceClient) {
+		c.proxy = proxy
+	}
+}
+
+// WithRootCAs sets the certificate authorities trusted when connecting over TLS
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *YahooFinanceClient) {
+		c.rootCAs = pool
+	}
+}
+
+// WithTransport sets the transport used to perform HTTP requests.
+// It takes precedence over WithProxy and WithRootCAs.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *YahooFinanceClient) {
+		c.transport = transport
+	}
+}
+
+// WithConcurrency sets the maximum number of parallel requests made by FetchMultipleStocks
+func WithConcurrency(n int) Option {
+	return func(c *YahooFinanceClient) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *YahooFinanceClient) {
+		c.retry = policy
+	}
+}
+
+// WithRateLimit limits the client to rps requests per second, allowing bursts
+// of up to burst requests. Every request made by the client, including retries
+// and concurrent batch fetches, shares the same limit. A non-positive rps
+// disables rate limiting.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *YahooFinanceClient) {
+		c.limiter = newRateLimiter(rps, burst)
+	}
+}
+
+// WithLogger sets the logger used for debug output
+func WithLogger(logger *log.Logger) Option {
+	return func(c *YahooFinanceClient) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// LoadCABundle loads a PEM-encoded CA bundle, adding it to the system's trusted certificates
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
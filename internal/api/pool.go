@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// forEachConcurrently calls fn for every index in [0, n) using at most limit
+// goroutines. Once ctx is done, the remaining indexes are passed to skip with
+// the context error instead of being handed to fn.
+func forEachConcurrently(ctx context.Context, n, limit int, fn func(i int), skip func(i int, err error)) {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					skip(i, err)
+					continue
+				}
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+}
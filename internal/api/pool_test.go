@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingServer serves a chart for the ticker in the request path once
+// released, tracking how many requests are in flight at once
+type blockingServer struct {
+	*httptest.Server
+	release     chan struct{}
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+// newBlockingServer creates a blockingServer. Requests for tickers for which
+// blocks returns false are served without waiting to be released.
+func newBlockingServer(t *testing.T, blocks func(ticker string) bool) *blockingServer {
+	t.Helper()
+
+	s := &blockingServer{release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for peak := s.maxInFlight.Load(); n > peak && !s.maxInFlight.CompareAndSwap(peak, n); peak = s.maxInFlight.Load() {
+		}
+
+		ticker := strings.TrimPrefix(r.URL.Path, "/v8/finance/chart/")
+		if blocks(ticker) {
+			select {
+			case <-s.release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		fmt.Fprintf(w, `{"chart":{"result":[{"meta":{"symbol":%q,"regularMarketPrice":1}}],"error":null}}`, ticker)
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(s.Release)
+
+	return s
+}
+
+// Release lets blocked requests, and any later ones, be served
+func (s *blockingServer) Release() {
+	select {
+	case <-s.release:
+	default:
+		close(s.release)
+	}
+}
+
+// waitInFlight waits until n requests are in flight, reporting whether they
+// were before giving up
+func (s *blockingServer) waitInFlight(n int32) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for s.inFlight.Load() < n {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+// newPoolTestClient creates a client for the server without rate limiting or retries
+func newPoolTestClient(s *blockingServer, concurrency int) *YahooFinanceClient {
+	return NewYahooFinanceClient(
+		WithBaseURL(s.URL),
+		WithConcurrency(concurrency),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithRateLimit(0, 0),
+	)
+}
+
+func TestFetchMultipleStocksOrderAndConcurrency(t *testing.T) {
+	const concurrency = 3
+	s := newBlockingServer(t, func(string) bool { return true })
+	client := newPoolTestClient(s, concurrency)
+
+	tickers := make([]string, 20)
+	for i := range tickers {
+		tickers[i] = fmt.Sprintf("T%02d", i)
+	}
+
+	type fetched struct {
+		results []Result
+		err     error
+	}
+	done := make(chan fetched)
+	go func() {
+		results, err := client.FetchMultipleStocks(context.Background(), tickers, "1d", "")
+		done <- fetched{results, err}
+	}()
+
+	// Give the pool a chance to exceed the limit before letting requests finish
+	if !s.waitInFlight(concurrency) {
+		t.Fatalf("in flight = %d, want %d", s.inFlight.Load(), concurrency)
+	}
+	time.Sleep(50 * time.Millisecond)
+	s.Release()
+
+	got := <-done
+	if got.err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", got.err)
+	}
+	if peak := s.maxInFlight.Load(); peak != concurrency {
+		t.Errorf("max in flight = %d, want %d", peak, concurrency)
+	}
+
+	if len(got.results) != len(tickers) {
+		t.Fatalf("results = %d, want %d", len(got.results), len(tickers))
+	}
+	for i, result := range got.results {
+		if result.Err != nil {
+			t.Errorf("%s: %v", tickers[i], result.Err)
+			continue
+		}
+		if result.Ticker != tickers[i] || result.Response.Chart.Result[0].Meta.Symbol != tickers[i] {
+			t.Errorf("result %d = %s (%s), want %s", i, result.Ticker, result.Response.Chart.Result[0].Meta.Symbol, tickers[i])
+		}
+	}
+}
+
+func TestFetchMultipleStocksCancelled(t *testing.T) {
+	// The first two tickers are served at once, the others never are
+	tickers := []string{"AAPL", "MSFT", "GOOG", "AMZN", "NVDA", "META", "TSLA"}
+	s := newBlockingServer(t, func(ticker string) bool { return ticker != "AAPL" && ticker != "MSFT" })
+	client := newPoolTestClient(s, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		s.waitInFlight(2)
+		cancel()
+	}()
+
+	start := time.Now()
+	results, err := client.FetchMultipleStocks(ctx, tickers, "1d", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %s, want the cancellation to stop the fetch", elapsed)
+	}
+
+	if len(results) != len(tickers) {
+		t.Fatalf("results = %d, want %d", len(results), len(tickers))
+	}
+	for i, result := range results {
+		if result.Ticker != tickers[i] {
+			t.Errorf("result %d = %s, want %s", i, result.Ticker, tickers[i])
+		}
+		if i < 2 {
+			if result.Err != nil {
+				t.Errorf("%s: %v, want the fetched data", result.Ticker, result.Err)
+			}
+		} else if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("%s: err = %v, want context.Canceled", result.Ticker, result.Err)
+		}
+	}
+}
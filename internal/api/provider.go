@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"stockterm/internal/model"
+)
+
+// Provider is a source of stock quotes and price history
+type Provider interface {
+	// FetchQuote fetches the latest quote for a ticker
+	FetchQuote(ctx context.Context, ticker string) (model.ChartResponse, error)
+	// FetchStockData fetches price history for a ticker over the given time
+	// range, at the given interval. An empty interval selects the provider's
+	// default interval for the time range.
+	FetchStockData(ctx context.Context, ticker, timeRange, interval string) (model.ChartResponse, error)
+	// FetchMultipleStocks fetches price history for several tickers. It returns
+	// one Result per ticker, in order, and a non-nil error only if ctx is done.
+	FetchMultipleStocks(ctx context.Context, tickers []string, timeRange, interval string) ([]Result, error)
+	// Search looks up tickers by symbol or company name, most relevant first
+	Search(ctx context.Context, query string) ([]model.SearchResult, error)
+	// Capabilities describes what the provider supports
+	Capabilities() Capabilities
+}
+
+// Result pairs a ticker with either its chart data or the error that prevented fetching it
+type Result struct {
+	// Ticker is the requested ticker
+	Ticker string
+	// Response is the chart data, valid only if Err is nil
+	Response model.ChartResponse
+	// Err is the error that occurred while fetching the ticker, if any
+	Err error
+	// StaleAsOf is set when Response holds previously fetched data served in
+	// place of a failed fetch, and holds the time that data was fetched
+	StaleAsOf time.Time
+}
+
+// Capabilities describes the features supported by a provider
+type Capabilities struct {
+	// Name is a short, human-readable name for the provider
+	Name string
+	// TimeRanges lists the time ranges accepted by FetchStockData
+	TimeRanges []string
+	// Intervals lists the data intervals the provider can return
+	Intervals []string
+	// DefaultIntervals maps time ranges to the interval used when none is given
+	DefaultIntervals map[string]string
+}
+
+// SupportsTimeRange reports whether the provider accepts the given time range
+func (c Capabilities) SupportsTimeRange(timeRange string) bool {
+	return contains(c.TimeRanges, timeRange)
+}
+
+// SupportsInterval reports whether the provider can return the given interval
+func (c Capabilities) SupportsInterval(interval string) bool {
+	return contains(c.Intervals, interval)
+}
+
+// IntervalFor returns interval, or the default interval for the time range if interval is empty
+func (c Capabilities) IntervalFor(timeRange, interval string) string {
+	if interval != "" {
+		return interval
+	}
+	return c.DefaultIntervals[timeRange]
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting the rate of outgoing requests.
+// A nil rateLimiter does not limit anything.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // maximum number of tokens
+	tokens float64 // tokens currently available, negative when reserved ahead
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter allowing rps requests per second with the given burst.
+// It returns nil if rps is not positive.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request may be made or ctx is done, and returns how long
+// it waited. It returns context.DeadlineExceeded right away, rather than
+// sleeping, if the request couldn't be made before ctx's deadline.
+func (l *rateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, ctx.Err()
+	}
+
+	// Refill the bucket and reserve a token, possibly ahead of time
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.release()
+		return 0, context.DeadlineExceeded
+	}
+
+	if err := sleep(ctx, wait); err != nil {
+		l.release()
+		return 0, err
+	}
+
+	return wait, nil
+}
+
+// release gives a reserved token back
+func (l *rateLimiter) release() {
+	l.mu.Lock()
+	l.tokens++
+	l.mu.Unlock()
+}
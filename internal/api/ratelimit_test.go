@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReturnsEarlyPastDeadline(t *testing.T) {
+	limiter := newRateLimiter(1, 1)
+
+	// The first request uses up the burst
+	if _, err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	// The next token is a second away, past the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := limiter.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait slept for %s before giving up", elapsed)
+	}
+
+	// The token was given back, so the next request waits about a second
+	// rather than two
+	wait, err := limiter.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if wait > 1100*time.Millisecond {
+		t.Errorf("waited %s, want at most a second", wait)
+	}
+}
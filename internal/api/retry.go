@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the backoff delay before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay. A Retry-After longer than MaxDelay stops retrying.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the default retry policy
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// backoff returns the delay before the given retry (starting at 1), using
+// exponential backoff with full jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	ceiling := p.BaseDelay << (retry - 1)
+	if ceiling <= 0 || (p.MaxDelay > 0 && ceiling > p.MaxDelay) {
+		ceiling = p.MaxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// delay returns how long to wait before retrying after err, and whether the
+// request should be retried at all
+func (p RetryPolicy) delay(retry int, err error) (time.Duration, bool) {
+	if retry >= p.MaxAttempts || !isRetryable(err) {
+		return 0, false
+	}
+
+	wait := p.backoff(retry)
+
+	// Honor Retry-After, unless the server asks us to wait longer than we are willing to
+	var fetchErr *FetchError
+	if errors.As(err, &fetchErr) && fetchErr.RetryAfter > 0 {
+		if p.MaxDelay > 0 && fetchErr.RetryAfter > p.MaxDelay {
+			return 0, false
+		}
+		if fetchErr.RetryAfter > wait {
+			wait = fetchErr.RetryAfter
+		}
+	}
+
+	return wait, true
+}
+
+// isRetryable reports whether a failed request may be safely retried.
+// Only throttling, server-side and network failures are retried.
+func isRetryable(err error) bool {
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		// Retrying without credentials is rejected the same way
+		return false
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrNetwork):
+		return true
+	case errors.Is(err, ErrServer):
+		return fetchErr.StatusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses a Retry-After header value, given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// sleep waits for d or until ctx is done, whichever comes first
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// chartBody is a minimal successful chart response
+const chartBody = `{"chart":{"result":[{"meta":{"symbol":"AAPL","regularMarketPrice":212.49,"previousClose":214.24}}],"error":null}}`
+
+// flakyServer serves the responses in turn, repeating the last one, and
+// counts the requests it receives
+type flakyServer struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+// response is a response served by a flakyServer
+type response struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+func newFlakyServer(t *testing.T, responses ...response) *flakyServer {
+	t.Helper()
+
+	s := &flakyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(s.requests.Add(1))
+		res := responses[min(n, len(responses))-1]
+		if res.retryAfter != "" {
+			w.Header().Set("Retry-After", res.retryAfter)
+		}
+		w.WriteHeader(res.status)
+		w.Write([]byte(res.body))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// newTestClient creates a client for the server without rate limiting
+func newTestClient(s *flakyServer, policy RetryPolicy) *YahooFinanceClient {
+	return NewYahooFinanceClient(
+		WithBaseURL(s.URL),
+		WithRetryPolicy(policy),
+		WithRateLimit(0, 0),
+	)
+}
+
+func TestRetryServerErrorThenSuccess(t *testing.T) {
+	s := newFlakyServer(t,
+		response{status: http.StatusInternalServerError},
+		response{status: http.StatusOK, body: chartBody},
+	)
+	client := newTestClient(s, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+	response, err := client.FetchStockData(context.Background(), "AAPL", "1d", "")
+	if err != nil {
+		t.Fatalf("FetchStockData: %v", err)
+	}
+	if got := response.Chart.Result[0].Meta.Symbol; got != "AAPL" {
+		t.Errorf("symbol = %q, want AAPL", got)
+	}
+	if got := s.requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	s := newFlakyServer(t,
+		response{status: http.StatusTooManyRequests, retryAfter: "1"},
+		response{status: http.StatusOK, body: chartBody},
+	)
+	client := newTestClient(s, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second})
+
+	start := time.Now()
+	if _, err := client.FetchStockData(context.Background(), "AAPL", "1d", ""); err != nil {
+		t.Fatalf("FetchStockData: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the 1s Retry-After", elapsed)
+	}
+	if got := s.requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestRetryAfterAboveMaxDelayStops(t *testing.T) {
+	s := newFlakyServer(t,
+		response{status: http.StatusTooManyRequests, retryAfter: "60"},
+		response{status: http.StatusOK, body: chartBody},
+	)
+	client := newTestClient(s, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	_, err := client.FetchStockData(context.Background(), "AAPL", "1d", "")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	if got := s.requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestRetryNeverRetriesClientErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		kind   error
+	}{
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusBadRequest, ErrUnsupported},
+		{http.StatusUnauthorized, ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			s := newFlakyServer(t,
+				response{status: tt.status, body: `{"chart":{"result":null,"error":{"code":"x","description":"rejected"}}}`},
+				response{status: http.StatusOK, body: chartBody},
+			)
+			client := newTestClient(s, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+			_, err := client.FetchStockData(context.Background(), "AAPL", "1d", "")
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("err = %v, want %v", err, tt.kind)
+			}
+			if got := s.requests.Load(); got != 1 {
+				t.Errorf("requests = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestRetryStopsAfterMaxAttempts(t *testing.T) {
+	s := newFlakyServer(t, response{status: http.StatusInternalServerError})
+	client := newTestClient(s, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+	_, err := client.FetchStockData(context.Background(), "AAPL", "1d", "")
+	if !errors.Is(err, ErrServer) {
+		t.Fatalf("err = %v, want ErrServer", err)
+	}
+	if got := s.requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
+
+func TestRetryStopsWhenContextIsCancelled(t *testing.T) {
+	s := newFlakyServer(t,
+		response{status: http.StatusServiceUnavailable, retryAfter: "5"},
+		response{status: http.StatusOK, body: chartBody},
+	)
+	client := newTestClient(s, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Second})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.FetchStockData(ctx, "AAPL", "1d", "")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %s, want the backoff to be cut short", elapsed)
+	}
+	if got := s.requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
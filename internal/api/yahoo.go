@@ -2,81 +2,401 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"stockterm/internal/model"
 )
 
+// yahooTimeRanges are the time ranges accepted by the Yahoo chart endpoint
+var yahooTimeRanges = []string{"1d", "5d", "1mo", "3mo", "6mo", "1y", "2y", "5y", "10y", "ytd", "max"}
+
+// yahooIntervals are the data intervals supported by the Yahoo chart endpoint
+var yahooIntervals = []string{"1m", "2m", "5m", "15m", "30m", "60m", "90m", "1h", "1d", "5d", "1wk", "1mo", "3mo"}
+
+// yahooDefaultIntervals are the intervals requested for each time range when none is given.
+// Yahoo only serves intraday intervals for recent data, so longer ranges use coarser intervals.
+var yahooDefaultIntervals = map[string]string{
+	"1d":  "2m",
+	"5d":  "15m",
+	"1mo": "30m",
+	"3mo": "1d",
+	"6mo": "1d",
+	"ytd": "1d",
+	"1y":  "1d",
+	"2y":  "1wk",
+	"5y":  "1wk",
+	"10y": "1mo",
+	"max": "1mo",
+}
+
+const (
+	// DefaultBaseURL is the default Yahoo Finance API endpoint
+	DefaultBaseURL = "https://query1.finance.yahoo.com"
+	// DefaultUserAgent is the default User-Agent sent with every request
+	DefaultUserAgent = "Mozilla/5.0 (compatible; StockTerm/1.0; +https://github.com/AyhamJo7/StockTerm)"
+	// DefaultTimeout is the default timeout of a single HTTP request
+	DefaultTimeout = 10 * time.Second
+	// DefaultConcurrency is the default number of parallel requests made by FetchMultipleStocks
+	DefaultConcurrency = 8
+	// DefaultRequestsPerSecond is the default sustained request rate
+	DefaultRequestsPerSecond = 10
+	// DefaultBurst is the default number of requests that may be made at once
+	DefaultBurst = 20
+)
+
 // YahooFinanceClient is a client for the Yahoo Finance API
 type YahooFinanceClient struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient  *http.Client
+	baseURL     string
+	userAgent   string
+	headers     http.Header
+	concurrency int
+	retry       RetryPolicy
+	limiter     *rateLimiter
+	logger      *log.Logger
+
+	// HTTP settings applied when the client is created
+	timeout   time.Duration
+	transport http.RoundTripper
+	proxy     *url.URL
+	rootCAs   *x509.CertPool
 }
 
 // NewYahooFinanceClient creates a new Yahoo Finance API client
-func NewYahooFinanceClient() *YahooFinanceClient {
-	return &YahooFinanceClient{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		baseURL: "https://query1.finance.yahoo.com/v8/finance/chart/%s?region=US&lang=en-US&includePrePost=false&interval=2m&useYfid=true&range=%s&corsDomain=finance.yahoo.com&.tsrc=finance",
+func NewYahooFinanceClient(opts ...Option) *YahooFinanceClient {
+	c := &YahooFinanceClient{
+		baseURL:     DefaultBaseURL,
+		userAgent:   DefaultUserAgent,
+		headers:     make(http.Header),
+		concurrency: DefaultConcurrency,
+		retry:       DefaultRetryPolicy(),
+		limiter:     newRateLimiter(DefaultRequestsPerSecond, DefaultBurst),
+		logger:      log.New(io.Discard, "", 0),
+		timeout:     DefaultTimeout,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.httpClient = &http.Client{
+		Timeout:   c.timeout,
+		Transport: c.newTransport(),
+	}
+
+	return c
 }
 
-// FetchStockData fetches stock data for a given ticker and time range
-func (c *YahooFinanceClient) FetchStockData(ctx context.Context, ticker, timeRange string) (model.ChartResponse, error) {
-	var response model.ChartResponse
+// newTransport returns the transport for the client's HTTP requests. A
+// transport set with WithTransport takes precedence over the proxy and CA
+// settings; otherwise the default transport is used, adjusted for those settings.
+func (c *YahooFinanceClient) newTransport() http.RoundTripper {
+	if c.transport != nil {
+		return c.transport
+	}
+
+	if c.proxy == nil && c.rootCAs == nil {
+		return http.DefaultTransport
+	}
 
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.proxy != nil {
+		transport.Proxy = http.ProxyURL(c.proxy)
+	}
+	if c.rootCAs != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: c.rootCAs}
+	}
+
+	return transport
+}
+
+// Ensure YahooFinanceClient implements Provider
+var _ Provider = (*YahooFinanceClient)(nil)
+
+// Capabilities describes the features supported by the Yahoo Finance API
+func (c *YahooFinanceClient) Capabilities() Capabilities {
+	return YahooCapabilities()
+}
+
+// YahooCapabilities describes the features supported by the Yahoo Finance
+// API, for checking settings without creating a client
+func YahooCapabilities() Capabilities {
+	return Capabilities{
+		Name:             "yahoo",
+		TimeRanges:       yahooTimeRanges,
+		Intervals:        yahooIntervals,
+		DefaultIntervals: yahooDefaultIntervals,
+	}
+}
+
+// FetchQuote fetches the latest quote for a ticker
+func (c *YahooFinanceClient) FetchQuote(ctx context.Context, ticker string) (model.ChartResponse, error) {
+	return c.FetchStockData(ctx, ticker, "1d", "")
+}
+
+// FetchStockData fetches stock data for a given ticker, time range and interval.
+// An empty interval selects a suitable interval for the time range.
+// Transient failures are retried according to the client's retry policy.
+// Failures are reported as a *FetchError describing the kind of failure.
+func (c *YahooFinanceClient) FetchStockData(ctx context.Context, ticker, timeRange, interval string) (model.ChartResponse, error) {
 	// Default to 1d if no time range is specified
 	if timeRange == "" {
 		timeRange = "1d"
 	}
+	interval = c.Capabilities().IntervalFor(timeRange, interval)
+	if interval == "" {
+		interval = "1d"
+	}
 
 	// Create the URL
-	url := fmt.Sprintf(c.baseURL, ticker, timeRange)
+	query := url.Values{}
+	query.Set("region", "US")
+	query.Set("lang", "en-US")
+	query.Set("includePrePost", "false")
+	query.Set("interval", interval)
+	query.Set("useYfid", "true")
+	query.Set("range", timeRange)
+	query.Set("corsDomain", "finance.yahoo.com")
+	query.Set(".tsrc", "finance")
+	chartURL := c.baseURL + "/v8/finance/chart/" + url.PathEscape(ticker) + "?" + query.Encode()
 
-	// Create a new request with the provided context
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	var response model.ChartResponse
+	err := c.retrying(ctx, ticker, func() error {
+		var err error
+		response, err = c.fetchChart(ctx, ticker, chartURL)
+		return err
+	})
 	if err != nil {
-		return response, fmt.Errorf("error creating request: %w", err)
+		return response, err
 	}
 
-	// Execute the request
-	res, err := c.httpClient.Do(req)
+	return response, checkTimeRange(ticker, timeRange, response)
+}
+
+// retrying calls fetch until it succeeds or fails in a way that the client's
+// retry policy doesn't retry, and returns its last error
+func (c *YahooFinanceClient) retrying(ctx context.Context, ticker string, fetch func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fetch()
+		if err == nil {
+			return nil
+		}
+
+		wait, ok := c.retry.delay(attempt, err)
+		if !ok {
+			return err
+		}
+
+		c.logger.Printf("retrying %s in %s (attempt %d failed: %v)", ticker, wait, attempt, err)
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// fetchChart performs a single chart request
+func (c *YahooFinanceClient) fetchChart(ctx context.Context, ticker, chartURL string) (model.ChartResponse, error) {
+	var response model.ChartResponse
+
+	res, err := c.get(ctx, ticker, chartURL)
 	if err != nil {
-		return response, fmt.Errorf("error fetching data: %w", err)
+		return response, err
 	}
 	defer res.Body.Close()
 
-	// Check for non-200 status codes
-	if res.StatusCode != http.StatusOK {
-		return response, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	if err := statusError(ticker, res); err != nil {
+		return response, err
 	}
 
 	// Decode the response
 	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		return response, fmt.Errorf("error decoding response: %w", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return response, ctxErr
+		}
+		return response, &FetchError{Ticker: ticker, Kind: ErrDecode, Err: err}
+	}
+
+	// A successful response without results means the ticker is unknown
+	if len(response.Chart.Result) == 0 {
+		fetchErr := &FetchError{Ticker: ticker, Kind: ErrNotFound}
+		if chartErr := response.Chart.Error; chartErr != nil && chartErr.Description != "" {
+			fetchErr.Err = errors.New(chartErr.Description)
+		}
+		return response, fetchErr
 	}
 
 	return response, nil
 }
 
-// FetchMultipleStocks fetches data for multiple tickers
-func (c *YahooFinanceClient) FetchMultipleStocks(ctx context.Context, tickers []string, timeRange string) ([]model.ChartResponse, error) {
-	var responses []model.ChartResponse
+// get waits for the rate limiter and performs a GET request. ticker names
+// the ticker or tickers the request is for, in errors and debug output.
+func (c *YahooFinanceClient) get(ctx context.Context, ticker, requestURL string) (*http.Response, error) {
+	// Wait for the rate limiter
+	wait, err := c.limiter.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		c.logger.Printf("rate limiter delayed %s by %s", ticker, wait)
+	}
+
+	// Create a new request with the provided context
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	c.setHeaders(req)
+
+	// Execute the request
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &FetchError{Ticker: ticker, Kind: ErrNetwork, Err: err}
+	}
+
+	return res, nil
+}
+
+// Search looks up tickers by symbol or company name using the search endpoint
+func (c *YahooFinanceClient) Search(ctx context.Context, query string) ([]model.SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("quotesCount", "10")
+	params.Set("newsCount", "0")
+	searchURL := c.baseURL + "/v1/finance/search?" + params.Encode()
 
-	for _, ticker := range tickers {
-		res, err := c.FetchStockData(ctx, ticker, timeRange)
-		if err != nil {
-			// Log the error but continue with other tickers
-			fmt.Printf("Error fetching data for %s: %v\n", ticker, err)
-			continue
+	var response model.SearchResponse
+	err := c.retrying(ctx, query, func() error {
+		return c.fetchJSON(ctx, query, searchURL, &response)
+	})
+
+	return response.Quotes, err
+}
+
+// fetchJSON performs a single request to an endpoint other than the chart
+// endpoint, and decodes the JSON response into v
+func (c *YahooFinanceClient) fetchJSON(ctx context.Context, ticker, requestURL string, v interface{}) error {
+	res, err := c.get(ctx, ticker, requestURL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := statusError(ticker, res); err != nil {
+		return err
+	}
+
+	// Decode the response
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
 		}
-		responses = append(responses, res)
+		return &FetchError{Ticker: ticker, Kind: ErrDecode, Err: err}
 	}
 
-	return responses, nil
+	return nil
+}
+
+// statusError returns the error for a response with a status other than 200
+// OK, or nil if the request succeeded
+func statusError(ticker string, res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusOK:
+		return nil
+	case res.StatusCode == http.StatusUnauthorized, res.StatusCode == http.StatusForbidden:
+		return &FetchError{Ticker: ticker, Kind: ErrUnauthorized, StatusCode: res.StatusCode}
+	case res.StatusCode == http.StatusNotFound:
+		return &FetchError{Ticker: ticker, Kind: ErrNotFound, StatusCode: res.StatusCode}
+	case res.StatusCode == http.StatusTooManyRequests:
+		return &FetchError{
+			Ticker:     ticker,
+			Kind:       ErrRateLimited,
+			StatusCode: res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
+	case res.StatusCode == http.StatusBadRequest, res.StatusCode == http.StatusUnprocessableEntity:
+		// Yahoo rejects unsupported range and interval combinations, explaining why in the body
+		return &FetchError{
+			Ticker:     ticker,
+			Kind:       ErrUnsupported,
+			StatusCode: res.StatusCode,
+			Err:        decodeChartError(res.Body),
+		}
+	default:
+		return &FetchError{
+			Ticker:     ticker,
+			Kind:       ErrServer,
+			StatusCode: res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+}
+
+// decodeChartError returns the error described in a chart response body, or nil if there is none
+func decodeChartError(body io.Reader) error {
+	var response model.ChartResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil
+	}
+
+	if chartErr := response.Chart.Error; chartErr != nil && chartErr.Description != "" {
+		return errors.New(chartErr.Description)
+	}
+
+	return nil
+}
+
+// checkTimeRange returns an error if the response says the ticker doesn't support the time range
+func checkTimeRange(ticker, timeRange string, response model.ChartResponse) error {
+	validRanges := response.Chart.Result[0].Meta.ValidRanges
+	if len(validRanges) == 0 || contains(validRanges, timeRange) {
+		return nil
+	}
+
+	return &FetchError{
+		Ticker: ticker,
+		Kind:   ErrUnsupported,
+		Err:    fmt.Errorf("range %s not available, valid ranges are %s", timeRange, strings.Join(validRanges, ", ")),
+	}
+}
+
+// setHeaders sets the User-Agent and custom headers on a request
+func (c *YahooFinanceClient) setHeaders(req *http.Request) {
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+}
+
+// FetchMultipleStocks fetches data for multiple tickers in parallel.
+// At most the configured concurrency limit of requests are in flight at once,
+// and one result per ticker is returned in the same order as the tickers. If
+// ctx is cancelled, the tickers that were not fetched carry the context error
+// and ctx.Err() is returned alongside the partial results.
+func (c *YahooFinanceClient) FetchMultipleStocks(ctx context.Context, tickers []string, timeRange, interval string) ([]Result, error) {
+	results := make([]Result, len(tickers))
+
+	forEachConcurrently(ctx, len(tickers), c.concurrency, func(i int) {
+		results[i].Ticker = tickers[i]
+		results[i].Response, results[i].Err = c.FetchStockData(ctx, tickers[i], timeRange, interval)
+	}, func(i int, err error) {
+		results[i] = Result{Ticker: tickers[i], Err: err}
+	})
+
+	return results, ctx.Err()
 }
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"stockterm/internal/model"
+)
+
+// newFixtureClient creates a client that replays the recorded fixtures
+func newFixtureClient() *YahooFinanceClient {
+	return NewYahooFinanceClient(
+		WithTransport(NewFixtureTransport("testdata", FixtureReplay)),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+}
+
+func TestFetchMultipleStocksFromFixtures(t *testing.T) {
+	client := newFixtureClient()
+
+	results, err := client.FetchMultipleStocks(context.Background(), []string{"AAPL", "MSFT", "INVALIDTICKER"}, "1d", "")
+	if err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	want := []model.StockData{
+		{
+			Ticker: "AAPL", LastPrice: 212.49, PreviousClose: 214.24, Currency: "USD",
+			DayHigh: 215.17, DayLow: 211.3, Volume: 70122748,
+			FiftyTwoWeekHigh: 220.2, FiftyTwoWeekLow: 164.08,
+		},
+		{
+			Ticker: "MSFT", LastPrice: 442.57, PreviousClose: 441.58, Currency: "USD",
+			DayHigh: 443.34, DayLow: 436.72, Volume: 13585619,
+			FiftyTwoWeekHigh: 443.34, FiftyTwoWeekLow: 309.45,
+		},
+	}
+	for i, w := range want {
+		if results[i].Err != nil {
+			t.Fatalf("%s: %v", w.Ticker, results[i].Err)
+		}
+
+		got := model.NewStockData(results[i].Response)
+		if got.Ticker != w.Ticker || got.LastPrice != w.LastPrice || got.PreviousClose != w.PreviousClose ||
+			got.Currency != w.Currency || got.DayHigh != w.DayHigh || got.DayLow != w.DayLow ||
+			got.Volume != w.Volume || got.FiftyTwoWeekHigh != w.FiftyTwoWeekHigh || got.FiftyTwoWeekLow != w.FiftyTwoWeekLow {
+			t.Errorf("%s: got %+v, want %+v", w.Ticker, got, w)
+		}
+		if change := w.LastPrice - w.PreviousClose; got.Change != change {
+			t.Errorf("%s: Change = %v, want %v", w.Ticker, got.Change, change)
+		}
+		if got.Open == 0 {
+			t.Errorf("%s: Open is unknown", w.Ticker)
+		}
+	}
+
+	if !errors.Is(results[2].Err, ErrNotFound) {
+		t.Errorf("INVALIDTICKER: err = %v, want ErrNotFound", results[2].Err)
+	}
+}
+
+func TestAverageVolumeFromFixtures(t *testing.T) {
+	client := newFixtureClient()
+
+	response, err := client.FetchStockData(context.Background(), "AAPL", "3mo", "1d")
+	if err != nil {
+		t.Fatalf("FetchStockData: %v", err)
+	}
+	if got := model.AverageVolume(response); got != 60188574 {
+		t.Errorf("AverageVolume = %d, want 60188574", got)
+	}
+
+	// Intraday charts don't tell the average daily volume
+	response, err = client.FetchStockData(context.Background(), "AAPL", "1d", "")
+	if err != nil {
+		t.Fatalf("FetchStockData: %v", err)
+	}
+	if got := model.AverageVolume(response); got != 0 {
+		t.Errorf("AverageVolume of an intraday chart = %d, want 0", got)
+	}
+}
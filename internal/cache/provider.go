@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"stockterm/internal/api"
+	"stockterm/internal/model"
+)
+
+// DefaultTTL is the lifetime of entries for time ranges without a configured TTL
+const DefaultTTL = time.Minute
+
+// ErrNoLastKnown is returned in offline mode for tickers without a last-known quote
+var ErrNoLastKnown = errors.New("no offline data")
+
+// TTLs maps time ranges to the lifetime of cached entries for that range
+type TTLs map[string]time.Duration
+
+// For returns the lifetime of cached entries for the time range
+func (t TTLs) For(timeRange string) time.Duration {
+	if ttl, ok := t[timeRange]; ok {
+		return ttl
+	}
+	return DefaultTTL
+}
+
+// Provider is an api.Provider that serves chart data from a Store while it is
+// fresh, and fetches and stores it using the wrapped provider otherwise.
+//
+// If a last-known store is configured, the last successful quote for every
+// ticker and time range is kept there and served, marked as stale, when the
+// network fails or times out, or the provider is offline.
+type Provider struct {
+	inner     api.Provider
+	store     *Store
+	ttls      TTLs
+	lastKnown *Store
+	refresh   bool
+	offline   bool
+}
+
+// Ensure Provider implements api.Provider
+var _ api.Provider = (*Provider)(nil)
+
+// NewProvider creates a caching provider wrapping inner. If store is nil,
+// responses are not cached.
+func NewProvider(inner api.Provider, store *Store, ttls TTLs) *Provider {
+	return &Provider{
+		inner: inner,
+		store: store,
+		ttls:  ttls,
+	}
+}
+
+// WithLastKnown sets the store holding the last successful quote for each
+// ticker and time range
+func (p *Provider) WithLastKnown(lastKnown *Store) *Provider {
+	p.lastKnown = lastKnown
+	return p
+}
+
+// Refreshing returns a copy of the provider that always fetches fresh data,
+// while still storing it in the cache
+func (p *Provider) Refreshing() *Provider {
+	refreshing := *p
+	refreshing.refresh = true
+	return &refreshing
+}
+
+// Offline returns a copy of the provider that never uses the network, serving
+// fresh cached data or else last-known quotes
+func (p *Provider) Offline() *Provider {
+	offline := *p
+	offline.offline = true
+	return &offline
+}
+
+// Capabilities describes the features supported by the wrapped provider
+func (p *Provider) Capabilities() api.Capabilities {
+	return p.inner.Capabilities()
+}
+
+// Search looks up tickers using the wrapped provider. Results are not cached,
+// and are unavailable offline.
+func (p *Provider) Search(ctx context.Context, query string) ([]model.SearchResult, error) {
+	if p.offline {
+		return nil, ErrNoLastKnown
+	}
+	return p.inner.Search(ctx, query)
+}
+
+// FetchQuote fetches the latest quote for a ticker from the wrapped provider
+func (p *Provider) FetchQuote(ctx context.Context, ticker string) (model.ChartResponse, error) {
+	if p.offline {
+		result := p.fetchLastKnown(ticker, "1d")
+		return result.Response, result.Err
+	}
+	return p.inner.FetchQuote(ctx, ticker)
+}
+
+// FetchStockData returns cached price history for a ticker if it is fresh,
+// and fetches and caches it otherwise
+func (p *Provider) FetchStockData(ctx context.Context, ticker, timeRange, interval string) (model.ChartResponse, error) {
+	results, err := p.FetchMultipleStocks(ctx, []string{ticker}, timeRange, interval)
+	if err != nil {
+		return model.ChartResponse{}, err
+	}
+	return results[0].Response, results[0].Err
+}
+
+// FetchMultipleStocks returns cached price history for the tickers that are
+// fresh, and fetches the others in a single batch from the wrapped provider.
+// Tickers that fail with a network error or time out, or all uncached tickers
+// when offline, are served from the last-known store with StaleAsOf set.
+func (p *Provider) FetchMultipleStocks(ctx context.Context, tickers []string, timeRange, interval string) ([]api.Result, error) {
+	results := make([]api.Result, len(tickers))
+
+	// Serve what we can from the cache and collect the rest
+	var missing []string
+	var missingIndexes []int
+	for i, ticker := range tickers {
+		if response, ok := p.lookup(p.key(ticker, timeRange, interval)); ok {
+			results[i] = api.Result{Ticker: ticker, Response: response}
+			continue
+		}
+		missing = append(missing, ticker)
+		missingIndexes = append(missingIndexes, i)
+	}
+
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	if p.offline {
+		for j, ticker := range missing {
+			results[missingIndexes[j]] = p.fetchLastKnown(ticker, timeRange)
+		}
+		return results, nil
+	}
+
+	fetched, err := p.inner.FetchMultipleStocks(ctx, missing, timeRange, interval)
+	for j, result := range fetched {
+		switch {
+		case result.Err == nil:
+			p.save(p.key(result.Ticker, timeRange, interval), result.Response)
+		case isNetworkFailure(result.Err):
+			// Fall back to the last-known quote, if there is one
+			if lastKnown := p.fetchLastKnown(result.Ticker, timeRange); lastKnown.Err == nil {
+				result = lastKnown
+			}
+		}
+		results[missingIndexes[j]] = result
+	}
+
+	return results, err
+}
+
+// key returns the cache key for a ticker, time range and interval
+func (p *Provider) key(ticker, timeRange, interval string) Key {
+	if timeRange == "" {
+		timeRange = "1d"
+	}
+	return Key{
+		Ticker:   ticker,
+		Range:    timeRange,
+		Interval: p.inner.Capabilities().IntervalFor(timeRange, interval),
+	}
+}
+
+// lookup returns the cached response for the key if it is still fresh
+func (p *Provider) lookup(key Key) (model.ChartResponse, bool) {
+	if p.refresh || p.store == nil {
+		return model.ChartResponse{}, false
+	}
+
+	entry, ok := p.store.Get(key)
+	if !ok || time.Since(entry.FetchedAt) >= p.ttls.For(key.Range) {
+		return model.ChartResponse{}, false
+	}
+
+	return entry.Response, true
+}
+
+// fetchLastKnown returns the last-known quote for a ticker and time range as a
+// stale result. Quotes of other time ranges are never served in its place.
+func (p *Provider) fetchLastKnown(ticker, timeRange string) api.Result {
+	if timeRange == "" {
+		timeRange = "1d"
+	}
+	if p.lastKnown != nil {
+		if entry, ok := p.lastKnown.Get(Key{Ticker: ticker, Range: timeRange}); ok {
+			return api.Result{Ticker: ticker, Response: entry.Response, StaleAsOf: entry.FetchedAt}
+		}
+	}
+	return api.Result{Ticker: ticker, Err: ErrNoLastKnown}
+}
+
+// save stores a response in the cache and as the last-known quote for its
+// ticker and time range. Failing to store is not an error for the caller.
+func (p *Provider) save(key Key, response model.ChartResponse) {
+	now := time.Now()
+	if p.store != nil {
+		_ = p.store.Put(key, response, now)
+	}
+	if p.lastKnown != nil {
+		_ = p.lastKnown.Put(Key{Ticker: key.Ticker, Range: key.Range}, response, now)
+	}
+}
+
+// isNetworkFailure reports whether err means the provider couldn't be reached
+// in time, as opposed to it answering with an error
+func isNetworkFailure(err error) bool {
+	if errors.Is(err, api.ErrNetwork) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
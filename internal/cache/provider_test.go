@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"stockterm/internal/api"
+)
+
+// fakeProvider returns err for every ticker once it is set, and a chart for
+// the requested range otherwise. It records the tickers it is asked for.
+type fakeProvider struct {
+	api.Provider
+	err     error
+	fetched []string
+}
+
+func (f *fakeProvider) Capabilities() api.Capabilities {
+	return api.Capabilities{}
+}
+
+func (f *fakeProvider) FetchMultipleStocks(ctx context.Context, tickers []string, timeRange, interval string) ([]api.Result, error) {
+	f.fetched = append(f.fetched, tickers...)
+
+	results := make([]api.Result, len(tickers))
+	for i, ticker := range tickers {
+		results[i] = api.Result{Ticker: ticker, Err: f.err}
+		if f.err == nil {
+			body := fmt.Sprintf(`{"chart":{"result":[{"meta":{"symbol":%q,"range":%q}}]}}`, ticker, timeRange)
+			if err := json.Unmarshal([]byte(body), &results[i].Response); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return results, nil
+}
+
+func TestLastKnownFallback(t *testing.T) {
+	inner := &fakeProvider{}
+	provider := NewProvider(inner, nil, nil).WithLastKnown(NewStore(t.TempDir()))
+
+	// Fetching successfully stores the last-known 1d quote
+	if _, err := provider.FetchMultipleStocks(context.Background(), []string{"AAPL"}, "1d", ""); err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+
+	inner.err = context.DeadlineExceeded
+
+	results, _ := provider.FetchMultipleStocks(context.Background(), []string{"AAPL"}, "1d", "")
+	if results[0].Err != nil {
+		t.Fatalf("timed out fetch wasn't served from last-known quotes: %v", results[0].Err)
+	}
+	if results[0].StaleAsOf.IsZero() {
+		t.Error("last-known quote isn't marked as stale")
+	}
+
+	// There is no last-known 5d quote, and the 1d one isn't served in its place
+	results, _ = provider.FetchMultipleStocks(context.Background(), []string{"AAPL"}, "5d", "")
+	if results[0].Err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want the fetch error", results[0].Err)
+	}
+}
+
+func TestLastKnownFallbackOnlyForNetworkFailures(t *testing.T) {
+	inner := &fakeProvider{}
+	provider := NewProvider(inner, nil, nil).WithLastKnown(NewStore(t.TempDir()))
+
+	if _, err := provider.FetchMultipleStocks(context.Background(), []string{"AAPL"}, "1d", ""); err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+
+	inner.err = &api.FetchError{Ticker: "AAPL", Kind: api.ErrNotFound}
+
+	results, _ := provider.FetchMultipleStocks(context.Background(), []string{"AAPL"}, "1d", "")
+	if results[0].Err != inner.err {
+		t.Errorf("err = %v, want the fetch error", results[0].Err)
+	}
+}
+
+func TestCacheFreshnessPerRange(t *testing.T) {
+	inner := &fakeProvider{}
+	store := NewStore(t.TempDir())
+	provider := NewProvider(inner, store, TTLs{"1d": time.Minute, "1y": 6 * time.Hour})
+
+	// Every entry is ten minutes old, which is only fresh for 1y
+	fetchedAt := time.Now().Add(-10 * time.Minute)
+	for _, timeRange := range []string{"1d", "1y", "5d"} {
+		if err := store.Put(Key{Ticker: "AAPL", Range: timeRange}, chartFor(t, "AAPL", "cached"), fetchedAt); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	tests := []struct {
+		timeRange string
+		cached    bool
+	}{
+		{"1d", false},
+		{"1y", true},
+		{"5d", false}, // the default TTL applies
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeRange, func(t *testing.T) {
+			inner.fetched = nil
+
+			results, err := provider.FetchMultipleStocks(context.Background(), []string{"AAPL"}, tt.timeRange, "")
+			if err != nil || results[0].Err != nil {
+				t.Fatalf("FetchMultipleStocks: %v, %v", err, results[0].Err)
+			}
+
+			if cached := chartRange(results[0].Response) == "cached"; cached != tt.cached {
+				t.Errorf("served from the cache = %t, want %t", cached, tt.cached)
+			}
+			if fetched := len(inner.fetched) > 0; fetched == tt.cached {
+				t.Errorf("fetched = %t, want %t", fetched, !tt.cached)
+			}
+
+			// Expired entries are replaced by the fetched data
+			if entry, _ := store.Get(Key{Ticker: "AAPL", Range: tt.timeRange}); !tt.cached && chartRange(entry.Response) != tt.timeRange {
+				t.Errorf("stored range = %q, want the fetched %q", chartRange(entry.Response), tt.timeRange)
+			}
+		})
+	}
+}
+
+func TestCacheFetchesOnlyMissingTickers(t *testing.T) {
+	inner := &fakeProvider{}
+	store := NewStore(t.TempDir())
+	provider := NewProvider(inner, store, nil)
+
+	if err := store.Put(Key{Ticker: "MSFT", Range: "1d"}, chartFor(t, "MSFT", "cached"), time.Now()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	results, err := provider.FetchMultipleStocks(context.Background(), []string{"AAPL", "MSFT", "GOOG"}, "1d", "")
+	if err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+
+	if want := []string{"AAPL", "GOOG"}; !reflect.DeepEqual(inner.fetched, want) {
+		t.Errorf("fetched = %q, want %q", inner.fetched, want)
+	}
+	for i, want := range []string{"AAPL", "MSFT", "GOOG"} {
+		if results[i].Ticker != want || results[i].Response.Chart.Result[0].Meta.Symbol != want {
+			t.Errorf("result %d = %s, want %s", i, results[i].Ticker, want)
+		}
+	}
+}
+
+func TestCacheRefreshing(t *testing.T) {
+	inner := &fakeProvider{}
+	store := NewStore(t.TempDir())
+	provider := NewProvider(inner, store, nil)
+
+	key := Key{Ticker: "AAPL", Range: "1d"}
+	fetchedAt := time.Now().Add(-time.Second)
+	if err := store.Put(key, chartFor(t, "AAPL", "cached"), fetchedAt); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	results, err := provider.Refreshing().FetchMultipleStocks(context.Background(), []string{"AAPL"}, "1d", "")
+	if err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+	if len(inner.fetched) != 1 || chartRange(results[0].Response) != "1d" {
+		t.Errorf("fetched = %q, range = %q, want a fresh fetch despite the fresh entry", inner.fetched, chartRange(results[0].Response))
+	}
+
+	// The fetched data is still written to the cache
+	entry, ok := store.Get(key)
+	if !ok || chartRange(entry.Response) != "1d" || !entry.FetchedAt.After(fetchedAt) {
+		t.Errorf("entry = %+v, want the refreshed data", entry)
+	}
+
+	// The original provider keeps serving fresh entries
+	inner.fetched = nil
+	if _, err := provider.FetchMultipleStocks(context.Background(), []string{"AAPL"}, "1d", ""); err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+	if len(inner.fetched) != 0 {
+		t.Errorf("fetched = %q, want the entry served from the cache", inner.fetched)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	inner := &fakeProvider{}
+	store := NewStore(t.TempDir())
+	lastKnown := NewStore(t.TempDir())
+	provider := NewProvider(inner, store, nil).WithLastKnown(lastKnown).Offline()
+
+	lastKnownAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	if err := lastKnown.Put(Key{Ticker: "AAPL", Range: "1d"}, chartFor(t, "AAPL", "last-known"), lastKnownAt); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Put(Key{Ticker: "MSFT", Range: "1d"}, chartFor(t, "MSFT", "cached"), time.Now()); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	results, err := provider.FetchMultipleStocks(context.Background(), []string{"AAPL", "MSFT", "GOOG"}, "1d", "")
+	if err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+	if len(inner.fetched) != 0 {
+		t.Errorf("fetched = %q offline, want no fetches", inner.fetched)
+	}
+
+	// Expired or missing entries are served from the last-known quotes, marked as stale
+	if aapl := results[0]; aapl.Err != nil || chartRange(aapl.Response) != "last-known" || !aapl.StaleAsOf.Equal(lastKnownAt) {
+		t.Errorf("AAPL = %v, %q, stale as of %s, want the last-known quote as of %s", aapl.Err, chartRange(aapl.Response), aapl.StaleAsOf, lastKnownAt)
+	}
+	// Fresh entries are served as usual
+	if msft := results[1]; msft.Err != nil || chartRange(msft.Response) != "cached" || !msft.StaleAsOf.IsZero() {
+		t.Errorf("MSFT = %v, %q, stale as of %s, want the fresh cached entry", msft.Err, chartRange(msft.Response), msft.StaleAsOf)
+	}
+	if goog := results[2]; !errors.Is(goog.Err, ErrNoLastKnown) {
+		t.Errorf("GOOG err = %v, want ErrNoLastKnown", goog.Err)
+	}
+}
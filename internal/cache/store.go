@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"stockterm/internal/model"
+)
+
+// Key identifies a cached chart response
+type Key struct {
+	Ticker   string `json:"ticker"`
+	Range    string `json:"range"`
+	Interval string `json:"interval"`
+}
+
+// String returns a readable representation of the key
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%s/%s", k.Ticker, k.Range, k.Interval)
+}
+
+// filename returns the name of the file holding the entry for the key.
+// Tickers may contain characters that are not valid in file names, so the key is hashed.
+func (k Key) filename() string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(k.Ticker) + "\x00" + k.Range + "\x00" + k.Interval))
+	return hex.EncodeToString(sum[:16]) + ".json"
+}
+
+// Entry is a cached chart response
+type Entry struct {
+	Key       Key                 `json:"key"`
+	FetchedAt time.Time           `json:"fetched_at"`
+	Response  model.ChartResponse `json:"response"`
+}
+
+// Store is an on-disk store of chart responses.
+// Entries are written atomically, so a Store may be shared by several processes.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store that keeps its entries in dir
+func NewStore(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Dir returns the directory the store keeps its entries in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the entry for the key. Missing or unreadable entries are reported as not found.
+func (s *Store) Get(key Key) (Entry, bool) {
+	var entry Entry
+
+	content, err := os.ReadFile(filepath.Join(s.dir, key.filename()))
+	if err != nil {
+		return entry, false
+	}
+
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return entry, false
+	}
+
+	return entry, true
+}
+
+// Put stores a response for the key
+func (s *Store) Put(key Key, response model.ChartResponse, fetchedAt time.Time) error {
+	content, err := json.Marshal(Entry{
+		Key:       key,
+		FetchedAt: fetchedAt,
+		Response:  response,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	// Create the directory if it doesn't exist
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it, so readers never see a partial entry
+	tmp, err := os.CreateTemp(s.dir, ".entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, key.filename())); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Clear removes all entries from the store and returns how many were removed
+func (s *Store) Clear() (int, error) {
+	files, err := s.entryFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Stats describes the contents of a store
+type Stats struct {
+	// Entries is the number of entries in the store
+	Entries int
+	// Fresh is the number of entries that have not expired yet
+	Fresh int
+	// Expired is the number of entries that have expired
+	Expired int
+	// Size is the total size of all entries in bytes
+	Size int64
+	// Oldest is the fetch time of the oldest entry
+	Oldest time.Time
+	// Newest is the fetch time of the newest entry
+	Newest time.Time
+}
+
+// Stats returns statistics about the store, using ttls to tell fresh entries from expired ones
+func (s *Store) Stats(ttls TTLs, now time.Time) (Stats, error) {
+	var stats Stats
+
+	files, err := s.entryFiles()
+	if err != nil {
+		return stats, err
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			continue
+		}
+
+		stats.Entries++
+		stats.Size += info.Size()
+
+		if now.Sub(entry.FetchedAt) < ttls.For(entry.Key.Range) {
+			stats.Fresh++
+		} else {
+			stats.Expired++
+		}
+
+		if stats.Oldest.IsZero() || entry.FetchedAt.Before(stats.Oldest) {
+			stats.Oldest = entry.FetchedAt
+		}
+		if entry.FetchedAt.After(stats.Newest) {
+			stats.Newest = entry.FetchedAt
+		}
+	}
+
+	return stats, nil
+}
+
+// entryFiles returns the paths of all entry files in the store
+func (s *Store) entryFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache entries: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"stockterm/internal/model"
+)
+
+// chartFor returns a chart response for a symbol, with its range set to
+// timeRange so tests can tell responses apart
+func chartFor(t *testing.T, symbol, timeRange string) model.ChartResponse {
+	t.Helper()
+
+	var response model.ChartResponse
+	body := fmt.Sprintf(`{"chart":{"result":[{"meta":{"symbol":%q,"range":%q}}]}}`, symbol, timeRange)
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// chartRange returns the range recorded in a chart response made by chartFor
+func chartRange(response model.ChartResponse) string {
+	if len(response.Chart.Result) == 0 {
+		return ""
+	}
+	return response.Chart.Result[0].Meta.Range
+}
+
+func TestStorePutGet(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "cache"))
+	key := Key{Ticker: "AAPL", Range: "1d", Interval: "5m"}
+	fetchedAt := time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)
+
+	if _, ok := store.Get(key); ok {
+		t.Fatal("Get found an entry in an empty store")
+	}
+
+	if err := store.Put(key, chartFor(t, "AAPL", "1d"), fetchedAt); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	entry, ok := store.Get(key)
+	if !ok {
+		t.Fatal("Get didn't find the stored entry")
+	}
+	if entry.Key != key || !entry.FetchedAt.Equal(fetchedAt) || entry.Response.Chart.Result[0].Meta.Symbol != "AAPL" {
+		t.Errorf("entry = %+v, want the stored one", entry)
+	}
+
+	// Tickers are case-insensitive, but ranges and intervals are distinct entries
+	if _, ok := store.Get(Key{Ticker: "aapl", Range: "1d", Interval: "5m"}); !ok {
+		t.Error("Get with a lower-case ticker didn't find the entry")
+	}
+	for _, other := range []Key{{Ticker: "AAPL", Range: "5d", Interval: "5m"}, {Ticker: "AAPL", Range: "1d", Interval: "1m"}} {
+		if _, ok := store.Get(other); ok {
+			t.Errorf("Get(%s) found the entry for %s", other, key)
+		}
+	}
+
+	// Putting again replaces the entry
+	if err := store.Put(key, chartFor(t, "AAPL", "replaced"), fetchedAt.Add(time.Minute)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if entry, _ := store.Get(key); chartRange(entry.Response) != "replaced" {
+		t.Errorf("range = %q after replacing the entry, want replaced", chartRange(entry.Response))
+	}
+}
+
+func TestStoreGetUnreadableEntry(t *testing.T) {
+	store := NewStore(t.TempDir())
+	key := Key{Ticker: "AAPL", Range: "1d"}
+	if err := os.WriteFile(filepath.Join(store.Dir(), key.filename()), []byte("{truncated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.Get(key); ok {
+		t.Error("Get returned an unreadable entry")
+	}
+}
+
+func TestStoreStatsAndClear(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)
+	ttls := TTLs{"1d": time.Minute, "1y": 6 * time.Hour}
+
+	entries := []struct {
+		key Key
+		age time.Duration
+	}{
+		{Key{Ticker: "AAPL", Range: "1d"}, 30 * time.Second}, // fresh
+		{Key{Ticker: "MSFT", Range: "1d"}, 2 * time.Minute},  // expired
+		{Key{Ticker: "AAPL", Range: "1y"}, time.Hour},        // fresh under the 1y TTL
+		{Key{Ticker: "AAPL", Range: "5d"}, 2 * time.Minute},  // expired under the default TTL
+	}
+	for _, e := range entries {
+		if err := store.Put(e.key, chartFor(t, e.key.Ticker, e.key.Range), now.Add(-e.age)); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	stats, err := store.Stats(ttls, now)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Entries != 4 || stats.Fresh != 2 || stats.Expired != 2 {
+		t.Errorf("entries, fresh, expired = %d, %d, %d, want 4, 2, 2", stats.Entries, stats.Fresh, stats.Expired)
+	}
+	if stats.Size <= 0 {
+		t.Errorf("size = %d, want the size of the entries", stats.Size)
+	}
+	if !stats.Oldest.Equal(now.Add(-time.Hour)) || !stats.Newest.Equal(now.Add(-30*time.Second)) {
+		t.Errorf("oldest, newest = %s, %s", stats.Oldest, stats.Newest)
+	}
+
+	removed, err := store.Clear()
+	if err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if removed != 4 {
+		t.Errorf("removed = %d, want 4", removed)
+	}
+	if stats, _ := store.Stats(ttls, now); stats.Entries != 0 {
+		t.Errorf("entries = %d after clearing, want 0", stats.Entries)
+	}
+}
+
+func TestStoreMissingDirectory(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "missing"))
+
+	if removed, err := store.Clear(); removed != 0 || err != nil {
+		t.Errorf("Clear = %d, %v, want 0, nil", removed, err)
+	}
+	if stats, err := store.Stats(nil, time.Now()); stats.Entries != 0 || err != nil {
+		t.Errorf("Stats = %+v, %v, want no entries", stats, err)
+	}
+}
+
+func TestTTLsFor(t *testing.T) {
+	ttls := TTLs{"1d": 2 * time.Minute, "max": 24 * time.Hour, "5d": 0}
+
+	tests := []struct {
+		timeRange string
+		want      time.Duration
+	}{
+		{"1d", 2 * time.Minute},
+		{"max", 24 * time.Hour},
+		{"5d", 0},
+		{"1y", DefaultTTL},
+	}
+
+	for _, tt := range tests {
+		if got := ttls.For(tt.timeRange); got != tt.want {
+			t.Errorf("For(%q) = %s, want %s", tt.timeRange, got, tt.want)
+		}
+	}
+}
@@ -1,135 +1,145 @@
 package config
 
 import (
-	"fmt"
-	"os"
 	"path/filepath"
-	"strings"
+	"time"
 )
 
 // Config represents the application configuration
 type Config struct {
 	// ConfigPath is the path to the configuration file
-	ConfigPath string
-	// WatchlistPath is the path to the watchlist file
-	WatchlistPath string
+	ConfigPath string `yaml:"-"`
+	// DataDir is the directory holding the watchlists and last-known quotes
+	DataDir string `yaml:"-"`
+	// WatchlistDir is the directory holding the named watchlists
+	WatchlistDir string `yaml:"-"`
+	// WatchlistPath is the path to a watchlist file used instead of the named
+	// watchlists; empty means the named watchlists are used
+	WatchlistPath string `yaml:"-"`
+	// CacheDir is the directory holding cached quotes
+	CacheDir string `yaml:"-"`
+	// LastKnownDir is the directory holding the last successful quote for each ticker and time range
+	LastKnownDir string `yaml:"-"`
 	// DefaultTimeRange is the default time range for stock data
-	DefaultTimeRange string
+	DefaultTimeRange string `yaml:"default_range"`
+	// DefaultInterval is the default data interval; empty selects one suited to the time range
+	DefaultInterval string `yaml:"default_interval,omitempty"`
 	// DefaultCurrency is the default currency for stock data
-	DefaultCurrency string
+	DefaultCurrency string `yaml:"default_currency"`
+	// HTTP configures how the quote provider is reached
+	HTTP HTTPConfig `yaml:"http"`
+	// MaxConcurrency is the maximum number of parallel requests when fetching several tickers
+	MaxConcurrency int `yaml:"max_concurrency"`
+	// Retry configures how failed requests are retried
+	Retry RetryConfig `yaml:"retry"`
+	// RateLimit configures the client-side request rate limit
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	// Cache configures the on-disk quote cache
+	Cache CacheConfig `yaml:"cache"`
+	// Table configures how stock tables are shown
+	Table TableConfig `yaml:"table"`
 }
 
-// DefaultConfig returns the default configuration
-func DefaultConfig() *Config {
-	// Get the user's home directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		// Fall back to current directory if home directory can't be determined
-		homeDir = "."
-	}
-
-	// Create the config directory if it doesn't exist
-	configDir := filepath.Join(homeDir, ".stockterm")
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(configDir, 0755); err != nil {
-			fmt.Printf("Warning: Failed to create config directory: %v\n", err)
-		}
-	}
-
-	return &Config{
-		ConfigPath:       filepath.Join(configDir, "config.yaml"),
-		WatchlistPath:    filepath.Join(configDir, "watchlist.txt"),
-		DefaultTimeRange: "1d",
-		DefaultCurrency:  "USD",
-	}
+// TableConfig configures how stock tables are shown
+type TableConfig struct {
+	// Columns lists the columns of stock tables in order, each optionally
+	// followed by its format, as in "volume:short"; empty means the default columns
+	Columns []string `yaml:"columns,omitempty"`
 }
 
-// LoadWatchlist loads the watchlist from the file
-func (c *Config) LoadWatchlist() ([]string, error) {
-	// Check if the watchlist file exists
-	if _, err := os.Stat(c.WatchlistPath); os.IsNotExist(err) {
-		// Create an empty watchlist file
-		if err := os.WriteFile(c.WatchlistPath, []byte(""), 0644); err != nil {
-			return nil, fmt.Errorf("failed to create watchlist file: %w", err)
-		}
-		return []string{}, nil
-	}
-
-	// Read the watchlist file
-	content, err := os.ReadFile(c.WatchlistPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read watchlist file: %w", err)
-	}
-
-	// If the file is empty, return an empty slice
-	if len(content) == 0 {
-		return []string{}, nil
-	}
-
-	// Split the content into an array by splitting with ","
-	watchlist := strings.Split(string(content), ",")
-
-	// Remove leading and trailing whitespaces from each element
-	for i, item := range watchlist {
-		watchlist[i] = strings.TrimSpace(item)
-	}
-
-	return watchlist, nil
+// CacheConfig configures the on-disk quote cache
+type CacheConfig struct {
+	// Enabled controls whether quotes are cached
+	Enabled bool `yaml:"enabled"`
+	// TTLs maps time ranges to how long quotes for that range stay fresh
+	TTLs map[string]time.Duration `yaml:"ttls"`
 }
 
-// SaveWatchlist saves the watchlist to the file
-func (c *Config) SaveWatchlist(watchlist []string) error {
-	// Join the watchlist into a comma-separated string
-	content := strings.Join(watchlist, ",")
-
-	// Create the directory if it doesn't exist
-	dir := filepath.Dir(c.WatchlistPath)
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
-	}
-
-	// Write the content to the file
-	if err := os.WriteFile(c.WatchlistPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write watchlist file: %w", err)
-	}
-
-	return nil
+// RateLimitConfig configures the client-side request rate limit
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained request rate; zero disables rate limiting
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// Burst is the number of requests that may be made at once
+	Burst int `yaml:"burst"`
 }
 
-// MigrateFromLegacy migrates the watchlist from the legacy location
-func (c *Config) MigrateFromLegacy(legacyPath string) error {
-	// Check if the legacy file exists
-	if _, err := os.Stat(legacyPath); os.IsNotExist(err) {
-		// Legacy file doesn't exist, nothing to migrate
-		return nil
-	}
+// HTTPConfig configures how the quote provider is reached
+type HTTPConfig struct {
+	// Endpoint is the base URL of the quote provider's API; empty means the provider's default
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Proxy is the URL of an HTTP proxy; empty means the proxy from the environment
+	Proxy string `yaml:"proxy,omitempty"`
+	// UserAgent is the User-Agent sent with every request; empty means the provider's default
+	UserAgent string `yaml:"user_agent,omitempty"`
+	// Headers are additional headers sent with every request
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Timeout is the timeout of a single request
+	Timeout time.Duration `yaml:"timeout"`
+	// CABundle is the path to a PEM file of additional trusted certificate authorities
+	CABundle string `yaml:"ca_bundle,omitempty"`
+}
 
-	// Check if the new watchlist file already exists
-	if _, err := os.Stat(c.WatchlistPath); err == nil {
-		// New watchlist file already exists, don't overwrite it
-		return nil
-	}
+// RetryConfig configures how failed requests are retried
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts per request, including the first one
+	MaxAttempts int `yaml:"max_attempts"`
+	// BaseDelay is the backoff delay before the first retry
+	BaseDelay time.Duration `yaml:"base_delay"`
+	// MaxDelay is the longest delay between two attempts
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
 
-	// Read the legacy file
-	content, err := os.ReadFile(legacyPath)
-	if err != nil {
-		return fmt.Errorf("failed to read legacy watchlist file: %w", err)
-	}
+// DefaultConfig returns the default configuration, with files kept in the
+// directories returned by DefaultDirs. It doesn't touch the file system;
+// directories are created when something is first written to them.
+func DefaultConfig() *Config {
+	dirs := DefaultDirs()
 
-	// Create the directory if it doesn't exist
-	dir := filepath.Dir(c.WatchlistPath)
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
+	cfg := &Config{
+		ConfigPath:       filepath.Join(dirs.Config, "config.yaml"),
+		DefaultTimeRange: "1d",
+		DefaultCurrency:  "USD",
+		HTTP: HTTPConfig{
+			Timeout: 10 * time.Second,
+		},
+		MaxConcurrency: 8,
+		Retry: RetryConfig{
+			MaxAttempts: 3,
+			BaseDelay:   500 * time.Millisecond,
+			MaxDelay:    5 * time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: 10,
+			Burst:             20,
+		},
+		Cache: CacheConfig{
+			Enabled: true,
+			TTLs: map[string]time.Duration{
+				"1d":  time.Minute,
+				"5d":  5 * time.Minute,
+				"1mo": 30 * time.Minute,
+				"3mo": time.Hour,
+				"6mo": time.Hour,
+				"ytd": 6 * time.Hour,
+				"1y":  6 * time.Hour,
+				"2y":  6 * time.Hour,
+				"5y":  24 * time.Hour,
+				"10y": 24 * time.Hour,
+				"max": 24 * time.Hour,
+			},
+		},
 	}
+	cfg.SetDataDir(dirs.Data)
+	cfg.CacheDir = dirs.Cache
 
-	// Write the content to the new file
-	if err := os.WriteFile(c.WatchlistPath, content, 0644); err != nil {
-		return fmt.Errorf("failed to write watchlist file: %w", err)
-	}
+	return cfg
+}
 
-	return nil
+// SetDataDir sets the data directory and the paths of the files kept in it,
+// including the quote cache
+func (c *Config) SetDataDir(dir string) {
+	c.DataDir = dir
+	c.WatchlistDir = filepath.Join(dir, "watchlists")
+	c.CacheDir = filepath.Join(dir, "cache")
+	c.LastKnownDir = filepath.Join(dir, "lastknown")
 }
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Dirs are the directories StockTerm keeps its files in
+type Dirs struct {
+	// Config is the directory holding the configuration file
+	Config string
+	// Data is the directory holding the watchlist and last-known quotes
+	Data string
+	// Cache is the directory holding cached quotes
+	Cache string
+}
+
+// DefaultDirs returns the default directories. On Linux, they follow the XDG
+// Base Directory Specification; elsewhere, everything is kept in ~/.stockterm.
+func DefaultDirs() Dirs {
+	if runtime.GOOS != "linux" {
+		homeDir := HomeDir()
+		return Dirs{
+			Config: homeDir,
+			Data:   homeDir,
+			Cache:  filepath.Join(homeDir, "cache"),
+		}
+	}
+
+	return Dirs{
+		Config: xdgDir("XDG_CONFIG_HOME", ".config"),
+		Data:   xdgDir("XDG_DATA_HOME", ".local", "share"),
+		Cache:  xdgDir("XDG_CACHE_HOME", ".cache"),
+	}
+}
+
+// HomeDir returns ~/.stockterm, the directory holding all files on platforms
+// other than Linux, and on Linux before XDG directories were used
+func HomeDir() string {
+	// Get the user's home directory
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		// Fall back to current directory if home directory can't be determined
+		homeDir = "."
+	}
+
+	return filepath.Join(homeDir, ".stockterm")
+}
+
+// xdgDir returns the stockterm directory inside the base directory named by an
+// XDG environment variable, or inside the given fallback under the home directory.
+// The specification says relative paths in the variables are invalid and must be ignored.
+func xdgDir(envVar string, fallback ...string) string {
+	if base := os.Getenv(envVar); filepath.IsAbs(base) {
+		return filepath.Join(base, "stockterm")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+
+	return filepath.Join(append(append([]string{homeDir}, fallback...), "stockterm")...)
+}
+
+// MigrateFromHomeDir moves the files kept in homeDir, as returned by HomeDir, to
+// the locations configured in c. The watchlist is moved as is, for
+// MigrateWatchlist to upgrade. Files that already exist at their new location
+// are left in place. homeDir is removed once it is empty, so the migration
+// happens only once. It does nothing if c keeps its files in homeDir.
+func (c *Config) MigrateFromHomeDir(homeDir string) error {
+	if filepath.Clean(c.DataDir) == filepath.Clean(homeDir) {
+		return nil
+	}
+
+	// Check if the old directory exists
+	if _, err := os.Stat(homeDir); os.IsNotExist(err) {
+		// Nothing to migrate
+		return nil
+	}
+
+	moves := []struct{ from, to string }{
+		{filepath.Join(homeDir, "config.yaml"), c.ConfigPath},
+		{filepath.Join(homeDir, "watchlist.txt"), filepath.Join(c.DataDir, "watchlist.txt")},
+		{filepath.Join(homeDir, "lastknown"), c.LastKnownDir},
+		{filepath.Join(homeDir, "cache"), c.CacheDir},
+	}
+
+	var errs []error
+	for _, move := range moves {
+		if err := moveIfAbsent(move.from, move.to); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Remove the old directory if everything was moved out of it
+	_ = os.Remove(homeDir)
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", homeDir, err)
+	}
+
+	return nil
+}
+
+// moveIfAbsent renames from to to, unless from doesn't exist or to already does
+func moveIfAbsent(from, to string) error {
+	if _, err := os.Stat(from); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(to); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("failed to move %s: %w", filepath.Base(from), err)
+	}
+
+	return nil
+}
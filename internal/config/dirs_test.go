@@ -0,0 +1,204 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// setHome points the home directory at a temporary directory and unsets the
+// XDG variables, returning the home directory
+func setHome(t *testing.T) string {
+	t.Helper()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG directories are only used on Linux")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME"} {
+		t.Setenv(name, "")
+	}
+	return home
+}
+
+func TestDefaultDirsFallback(t *testing.T) {
+	home := setHome(t)
+
+	want := Dirs{
+		Config: filepath.Join(home, ".config", "stockterm"),
+		Data:   filepath.Join(home, ".local", "share", "stockterm"),
+		Cache:  filepath.Join(home, ".cache", "stockterm"),
+	}
+	if got := DefaultDirs(); got != want {
+		t.Errorf("DefaultDirs = %+v, want %+v", got, want)
+	}
+	if got, want := HomeDir(), filepath.Join(home, ".stockterm"); got != want {
+		t.Errorf("HomeDir = %s, want %s", got, want)
+	}
+}
+
+func TestDefaultDirsXDGOverrides(t *testing.T) {
+	home := setHome(t)
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	// Relative paths are invalid and ignored
+	t.Setenv("XDG_CACHE_HOME", "relative/cache")
+
+	want := Dirs{
+		Config: filepath.Join(base, "config", "stockterm"),
+		Data:   filepath.Join(base, "data", "stockterm"),
+		Cache:  filepath.Join(home, ".cache", "stockterm"),
+	}
+	if got := DefaultDirs(); got != want {
+		t.Errorf("DefaultDirs = %+v, want %+v", got, want)
+	}
+
+	cfg := DefaultConfig()
+	if want := filepath.Join(base, "config", "stockterm", "config.yaml"); cfg.ConfigPath != want {
+		t.Errorf("ConfigPath = %s, want %s", cfg.ConfigPath, want)
+	}
+	if want := filepath.Join(base, "data", "stockterm", "watchlists"); cfg.WatchlistDir != want {
+		t.Errorf("WatchlistDir = %s, want %s", cfg.WatchlistDir, want)
+	}
+	if want := filepath.Join(home, ".cache", "stockterm"); cfg.CacheDir != want {
+		t.Errorf("CacheDir = %s, want %s", cfg.CacheDir, want)
+	}
+}
+
+func TestMigrateFromHomeDir(t *testing.T) {
+	root := t.TempDir()
+	homeDir := filepath.Join(root, ".stockterm")
+	writeTestFile(t, filepath.Join(homeDir, "config.yaml"), "default_range: 5d\n")
+	writeTestFile(t, filepath.Join(homeDir, "watchlist.txt"), "AAPL,MSFT")
+	writeTestFile(t, filepath.Join(homeDir, "lastknown", "entry.json"), "{}")
+	writeTestFile(t, filepath.Join(homeDir, "cache", "entry.json"), "{}")
+
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(root, "config", "config.yaml")
+	cfg.SetDataDir(filepath.Join(root, "data"))
+	cfg.CacheDir = filepath.Join(root, "cache")
+
+	if err := cfg.MigrateFromHomeDir(homeDir); err != nil {
+		t.Fatalf("MigrateFromHomeDir: %v", err)
+	}
+
+	for _, path := range []string{
+		cfg.ConfigPath,
+		filepath.Join(cfg.DataDir, "watchlist.txt"),
+		filepath.Join(cfg.LastKnownDir, "entry.json"),
+		filepath.Join(cfg.CacheDir, "entry.json"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s wasn't moved: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(homeDir); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after migrating: %v", homeDir, err)
+	}
+
+	// Running again does nothing
+	if err := cfg.MigrateFromHomeDir(homeDir); err != nil {
+		t.Errorf("MigrateFromHomeDir again: %v", err)
+	}
+}
+
+func TestMigrateFromHomeDirKeepsExistingFiles(t *testing.T) {
+	root := t.TempDir()
+	homeDir := filepath.Join(root, ".stockterm")
+	writeTestFile(t, filepath.Join(homeDir, "config.yaml"), "default_range: 5d\n")
+	writeTestFile(t, filepath.Join(homeDir, "watchlist.txt"), "AAPL")
+
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(root, "config", "config.yaml")
+	cfg.SetDataDir(filepath.Join(root, "data"))
+	writeTestFile(t, cfg.ConfigPath, "default_range: 1y\n")
+
+	if err := cfg.MigrateFromHomeDir(homeDir); err != nil {
+		t.Fatalf("MigrateFromHomeDir: %v", err)
+	}
+
+	content, err := os.ReadFile(cfg.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "default_range: 1y\n" {
+		t.Errorf("config file = %q, want the existing one", content)
+	}
+	// The file that couldn't be moved keeps the old directory around
+	if _, err := os.Stat(filepath.Join(homeDir, "config.yaml")); err != nil {
+		t.Errorf("old config file was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.DataDir, "watchlist.txt")); err != nil {
+		t.Errorf("watchlist.txt wasn't moved: %v", err)
+	}
+}
+
+func TestMigrateFromHomeDirInPlace(t *testing.T) {
+	homeDir := t.TempDir()
+	writeTestFile(t, filepath.Join(homeDir, "watchlist.txt"), "AAPL")
+
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(homeDir, "config.yaml")
+	cfg.SetDataDir(homeDir)
+
+	if err := cfg.MigrateFromHomeDir(homeDir); err != nil {
+		t.Fatalf("MigrateFromHomeDir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, "watchlist.txt")); err != nil {
+		t.Errorf("watchlist.txt was moved: %v", err)
+	}
+}
+
+func TestResolveMigratesHomeDir(t *testing.T) {
+	home := setHome(t)
+	writeTestFile(t, filepath.Join(home, ".stockterm", "config.yaml"), "default_range: 5d\n")
+	writeTestFile(t, filepath.Join(home, ".stockterm", "watchlist.txt"), "AAPL,MSFT")
+
+	noEnv := func(string) (string, bool) { return "", false }
+	cfg, warnings, err := Resolve(Overrides{}, noEnv)
+	if err != nil || len(warnings) > 0 {
+		t.Fatalf("Resolve: %v, warnings %q", err, warnings)
+	}
+
+	if cfg.DefaultTimeRange != "5d" {
+		t.Errorf("default range = %s, want the migrated 5d", cfg.DefaultTimeRange)
+	}
+	assertSymbols(t, cfg, DefaultWatchlist, []string{"AAPL", "MSFT"})
+	if _, err := os.Stat(filepath.Join(home, ".stockterm")); !os.IsNotExist(err) {
+		t.Errorf("~/.stockterm still exists after migrating: %v", err)
+	}
+}
+
+func TestResolveSkipsMigrationWithExplicitPaths(t *testing.T) {
+	home := setHome(t)
+	writeTestFile(t, filepath.Join(home, ".stockterm", "watchlist.txt"), "AAPL")
+
+	noEnv := func(string) (string, bool) { return "", false }
+	if _, _, err := Resolve(Overrides{DataDir: t.TempDir()}, noEnv); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".stockterm", "watchlist.txt")); err != nil {
+		t.Errorf("watchlist.txt was moved despite an explicit data directory: %v", err)
+	}
+}
+
+func TestResolveWithoutFilesWritesNothing(t *testing.T) {
+	home := setHome(t)
+
+	noEnv := func(string) (string, bool) { return "", false }
+	if _, _, err := Resolve(Overrides{}, noEnv); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	entries, err := os.ReadDir(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("home directory holds %d entries, want none without anything to migrate", len(entries))
+	}
+}
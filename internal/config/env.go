@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables that override the configuration
+const EnvPrefix = "STOCKTERM_"
+
+// Overrides holds paths given as command-line flags. Empty fields are not overridden.
+type Overrides struct {
+	// ConfigPath overrides the path to the configuration file
+	ConfigPath string
+	// DataDir overrides the data directory
+	DataDir string
+	// WatchlistPath sets a watchlist file to use instead of the named watchlists
+	WatchlistPath string
+}
+
+// Resolve builds the configuration from, in increasing order of precedence:
+//
+//  1. the defaults
+//  2. the configuration file
+//  3. STOCKTERM_* environment variables
+//  4. command-line flags
+//
+// Only paths can be set by flags. Paths are resolved before the configuration
+// file is read, since they include the location of the file itself. It returns
+// warnings about unknown keys in the configuration file. On error, the returned
+// configuration is still usable, e.g. to fix the configuration file.
+func Resolve(flags Overrides, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	cfg := DefaultConfig()
+
+	// Resolve the paths, letting flags override the environment
+	envPaths := Overrides{
+		ConfigPath:    env(lookupEnv, "CONFIG"),
+		DataDir:       env(lookupEnv, "DATA_DIR"),
+		WatchlistPath: env(lookupEnv, "WATCHLIST"),
+	}
+	cfg.applyPaths(envPaths)
+	cfg.applyPaths(flags)
+
+	// Move files from ~/.stockterm to the default directories, unless paths
+	// were chosen explicitly
+	var warnings []string
+	if envPaths == (Overrides{}) && flags == (Overrides{}) {
+		if err := cfg.MigrateFromHomeDir(HomeDir()); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	if err := cfg.MigrateWatchlist(); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	fileWarnings, err := cfg.Load()
+	warnings = append(warnings, fileWarnings...)
+	if err != nil {
+		return cfg, warnings, err
+	}
+
+	if err := cfg.ApplyEnv(lookupEnv); err != nil {
+		return cfg, warnings, err
+	}
+
+	return cfg, warnings, nil
+}
+
+// ApplyEnv overrides configuration keys with the environment variables named
+// after them, e.g. STOCKTERM_HTTP_PROXY for http.proxy. Keys holding lists,
+// such as table.columns, take comma-separated values. Keys holding maps, such
+// as http.headers, can't be overridden this way.
+func (c *Config) ApplyEnv(lookupEnv func(string) (string, bool)) error {
+	for _, key := range Keys() {
+		name := EnvName(key)
+		value, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		value, err := envValue(key, value)
+		if err != nil {
+			return fmt.Errorf("invalid environment variable %s: %w", name, err)
+		}
+		if err := c.Set(key, value); err != nil {
+			return fmt.Errorf("invalid environment variable %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// EnvName returns the name of the environment variable overriding a configuration key
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Keys returns the configuration keys holding single values, such as "http.proxy"
+func Keys() []string {
+	return leafKeys(reflect.TypeOf(Config{}), "")
+}
+
+// envValue returns the YAML value of an environment variable overriding key.
+// Values of keys holding lists are split at commas, unless they are written
+// as YAML flow sequences, such as [ticker, price].
+func envValue(key, value string) (string, error) {
+	t := reflect.TypeOf(Config{})
+	for _, name := range strings.Split(key, ".") {
+		// Keys come from Keys, so every name exists
+		t, _ = keyType(t, name)
+	}
+	if t.Kind() != reflect.Slice || strings.HasPrefix(strings.TrimSpace(value), "[") {
+		return value, nil
+	}
+
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	content, err := yaml.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// applyPaths applies the non-empty path overrides. Overriding the data
+// directory moves every file kept in it, unless that file's path is also overridden.
+func (c *Config) applyPaths(overrides Overrides) {
+	if overrides.ConfigPath != "" {
+		c.ConfigPath = overrides.ConfigPath
+	}
+	if overrides.DataDir != "" {
+		c.SetDataDir(overrides.DataDir)
+	}
+	if overrides.WatchlistPath != "" {
+		c.WatchlistPath = overrides.WatchlistPath
+	}
+}
+
+// env returns the value of the STOCKTERM_ environment variable with the given suffix
+func env(lookupEnv func(string) (string, bool), suffix string) string {
+	value, _ := lookupEnv(EnvPrefix + suffix)
+	return value
+}
+
+// leafKeys returns the dotted keys of the non-struct, non-map values in a struct type
+func leafKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			keys = append(keys, leafKeys(field.Type, prefix+tag+".")...)
+		case reflect.Map:
+			// Maps have no fixed keys
+		default:
+			keys = append(keys, prefix+tag)
+		}
+	}
+
+	return keys
+}
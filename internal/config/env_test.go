@@ -0,0 +1,54 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnvLists(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"ticker,price", []string{"ticker", "price"}},
+		{" ticker , price:4 ,", []string{"ticker", "price:4"}},
+		{"[ticker, volume:short]", []string{"ticker", "volume:short"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cfg := DefaultConfig()
+			lookupEnv := func(name string) (string, bool) {
+				return tt.value, name == "STOCKTERM_TABLE_COLUMNS"
+			}
+
+			if err := cfg.ApplyEnv(lookupEnv); err != nil {
+				t.Fatalf("ApplyEnv: %v", err)
+			}
+			// An empty list means the default columns, however it is stored
+			if got := cfg.Table.Columns; len(got) != len(tt.want) || len(got) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columns = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnvScalars(t *testing.T) {
+	cfg := DefaultConfig()
+	env := map[string]string{
+		"STOCKTERM_DEFAULT_RANGE": "5d",
+		"STOCKTERM_HTTP_PROXY":    "http://proxy.example.com:3128",
+	}
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	if err := cfg.ApplyEnv(lookupEnv); err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+	if cfg.DefaultTimeRange != "5d" || cfg.HTTP.Proxy != "http://proxy.example.com:3128" {
+		t.Errorf("range, proxy = %q, %q", cfg.DefaultTimeRange, cfg.HTTP.Proxy)
+	}
+}
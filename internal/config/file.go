@@ -0,0 +1,307 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"stockterm/internal/api"
+)
+
+// Load reads the configuration file at ConfigPath on top of the current
+// values, so that keys missing from the file keep their defaults. A missing
+// file is not an error. Unknown keys are ignored and reported as warnings.
+func (c *Config) Load() ([]string, error) {
+	content, err := os.ReadFile(c.ConfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", c.ConfigPath, err)
+	}
+
+	// An empty file holds no settings
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	var warnings []string
+	for _, key := range unknownKeys(doc.Content[0], reflect.TypeOf(*c), "") {
+		warnings = append(warnings, fmt.Sprintf("unknown config key '%s' in %s", key, c.ConfigPath))
+	}
+
+	if err := doc.Decode(c); err != nil {
+		return warnings, fmt.Errorf("invalid config file %s: %w", c.ConfigPath, err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return warnings, fmt.Errorf("invalid config file %s: %w", c.ConfigPath, err)
+	}
+
+	return warnings, nil
+}
+
+// Save writes the configuration to the file at ConfigPath
+func (c *Config) Save() error {
+	content, err := encodeYAML(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	// Create the directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(c.ConfigPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if err := os.WriteFile(c.ConfigPath, content, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// Validate checks that the configuration values are usable
+func (c *Config) Validate() error {
+	var errs []error
+
+	capabilities := api.YahooCapabilities()
+	if !capabilities.SupportsTimeRange(c.DefaultTimeRange) {
+		errs = append(errs, fmt.Errorf("default_range must be one of %s, got '%s'", strings.Join(capabilities.TimeRanges, ", "), c.DefaultTimeRange))
+	}
+	if c.DefaultInterval != "" && !capabilities.SupportsInterval(c.DefaultInterval) {
+		errs = append(errs, fmt.Errorf("default_interval must be empty or one of %s, got '%s'", strings.Join(capabilities.Intervals, ", "), c.DefaultInterval))
+	}
+	if len(c.DefaultCurrency) != 3 {
+		errs = append(errs, fmt.Errorf("default_currency must be a three-letter currency code, got '%s'", c.DefaultCurrency))
+	}
+	if c.HTTP.Endpoint != "" {
+		if u, err := url.Parse(c.HTTP.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("http.endpoint must be an absolute URL, got '%s'", c.HTTP.Endpoint))
+		}
+	}
+	if c.HTTP.Proxy != "" {
+		if u, err := url.Parse(c.HTTP.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("http.proxy must be an absolute URL, got '%s'", c.HTTP.Proxy))
+		}
+	}
+	if c.HTTP.Timeout <= 0 {
+		errs = append(errs, errors.New("http.timeout must be positive"))
+	}
+	if c.MaxConcurrency < 1 {
+		errs = append(errs, errors.New("max_concurrency must be at least 1"))
+	}
+	if c.Retry.MaxAttempts < 1 {
+		errs = append(errs, errors.New("retry.max_attempts must be at least 1"))
+	}
+	if c.Retry.BaseDelay < 0 || c.Retry.MaxDelay < 0 {
+		errs = append(errs, errors.New("retry delays must not be negative"))
+	}
+	if c.Retry.MaxDelay < c.Retry.BaseDelay {
+		errs = append(errs, errors.New("retry.max_delay must not be less than retry.base_delay"))
+	}
+	if c.RateLimit.RequestsPerSecond < 0 {
+		errs = append(errs, errors.New("rate_limit.requests_per_second must not be negative"))
+	}
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst < 1 {
+		errs = append(errs, errors.New("rate_limit.burst must be at least 1"))
+	}
+	for timeRange, ttl := range c.Cache.TTLs {
+		if ttl < 0 {
+			errs = append(errs, fmt.Errorf("cache.ttls.%s must not be negative", timeRange))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Get returns the value of a configuration key, such as "http.proxy", formatted
+// as YAML. An empty key returns the whole configuration.
+func (c *Config) Get(key string) (string, error) {
+	var root yaml.Node
+	if err := root.Encode(c); err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	node := &root
+	if key != "" {
+		var err error
+		if node, err = findKey(&root, reflect.TypeOf(*c), key, false); err != nil {
+			return "", err
+		}
+		if node == nil {
+			return "", nil
+		}
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+
+	content, err := encodeYAML(node)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	return strings.TrimRight(string(content), "\n"), nil
+}
+
+// Set sets a configuration key, such as "http.proxy", to a YAML-formatted
+// value. The configuration is left unchanged if the result is invalid.
+func (c *Config) Set(key, value string) error {
+	var root yaml.Node
+	if err := root.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	node, err := findKey(&root, reflect.TypeOf(*c), key, true)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	if len(doc.Content) == 0 {
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+	} else {
+		*node = *doc.Content[0]
+	}
+
+	updated := c.clone()
+	if err := root.Decode(updated); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+
+	*c = *updated
+	return nil
+}
+
+// encodeYAML encodes v as YAML with two-space indentation
+func encodeYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clone returns a deep copy of the configuration
+func (c *Config) clone() *Config {
+	clone := *c
+	clone.HTTP.Headers = maps.Clone(c.HTTP.Headers)
+	clone.Cache.TTLs = maps.Clone(c.Cache.TTLs)
+	clone.Table.Columns = slices.Clone(c.Table.Columns)
+	return &clone
+}
+
+// findKey returns the node for a dotted key within root, whose values are of
+// type t. If the key is valid but absent, it is added when create is true, and
+// nil is returned otherwise.
+func findKey(root *yaml.Node, t reflect.Type, key string, create bool) (*yaml.Node, error) {
+	node := root
+	path := strings.Split(key, ".")
+
+	for i, name := range path {
+		fieldType, ok := keyType(t, name)
+		if !ok || node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("unknown config key '%s'", strings.Join(path[:i+1], "."))
+		}
+
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == name {
+				child = node.Content[j+1]
+				break
+			}
+		}
+
+		if child == nil {
+			if !create {
+				return nil, nil
+			}
+
+			child = &yaml.Node{Kind: yaml.ScalarNode}
+			if kind := fieldType.Kind(); kind == reflect.Struct || kind == reflect.Map {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, child)
+		}
+
+		node = child
+		t = fieldType
+	}
+
+	return node, nil
+}
+
+// unknownKeys returns the dotted keys in a mapping node that don't correspond to values of type t
+func unknownKeys(node *yaml.Node, t reflect.Type, prefix string) []string {
+	if node.Kind != yaml.MappingNode || t.Kind() == reflect.Map {
+		return nil
+	}
+
+	var unknown []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+
+		fieldType, ok := keyType(t, name)
+		if !ok {
+			unknown = append(unknown, prefix+name)
+			continue
+		}
+
+		if fieldType.Kind() == reflect.Struct {
+			unknown = append(unknown, unknownKeys(node.Content[i+1], fieldType, prefix+name+".")...)
+		}
+	}
+
+	return unknown
+}
+
+// keyType returns the type of the value stored under name in a value of type t.
+// Struct fields are named by their yaml tag, and maps accept any name.
+func keyType(t reflect.Type, name string) (reflect.Type, bool) {
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem(), true
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if tag == "-" || !field.IsExported() {
+				continue
+			}
+			if tag == "" {
+				tag = strings.ToLower(field.Name)
+			}
+			if tag == name {
+				return field.Type, true
+			}
+		}
+	}
+
+	return nil, false
+}
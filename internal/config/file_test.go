@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetValidatesRangeAndInterval(t *testing.T) {
+	tests := []struct {
+		key, value string
+		valid      bool
+	}{
+		{"default_range", "5d", true},
+		{"default_range", "ytd", true},
+		{"default_range", "7x", false},
+		{"default_range", `""`, false},
+		{"default_interval", "1wk", true},
+		{"default_interval", `""`, true},
+		{"default_interval", "7x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			cfg := DefaultConfig()
+			err := cfg.Set(tt.key, tt.value)
+			if tt.valid && err != nil {
+				t.Errorf("Set: %v", err)
+			}
+			if !tt.valid {
+				if err == nil {
+					t.Error("Set succeeded, want an error")
+				}
+				// The configuration is left unchanged
+				if cfg.DefaultTimeRange != "1d" || cfg.DefaultInterval != "" {
+					t.Errorf("range, interval = %q, %q after a failed Set", cfg.DefaultTimeRange, cfg.DefaultInterval)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidRange(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfigPath = filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg.ConfigPath, []byte("default_range: 7x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cfg.Load(); err == nil || !strings.Contains(err.Error(), "default_range") {
+		t.Errorf("Load: err = %v, want an error about default_range", err)
+	}
+}
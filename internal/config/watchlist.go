@@ -0,0 +1,572 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"stockterm/internal/fsutil"
+	"stockterm/internal/symbol"
+)
+
+// DefaultWatchlist is the name of the watchlist used until another one is selected
+const DefaultWatchlist = "default"
+
+// WatchlistVersion is the version of the watchlist file format written by SaveWatchlist
+const WatchlistVersion = 1
+
+// watchlistExt is the extension of watchlist files in the watchlist directory
+const watchlistExt = ".yaml"
+
+// legacyWatchlistExt is the extension of watchlist files in the watchlist
+// directory that hold comma-separated tickers, before entries had metadata
+const legacyWatchlistExt = ".txt"
+
+// currentWatchlistFile is the file in the watchlist directory holding the name of the watchlist in use
+const currentWatchlistFile = ".current"
+
+// validWatchlistName matches the names allowed for watchlists, which are used as file names
+var validWatchlistName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+var (
+	// ErrWatchlistNotFound is returned for operations on a watchlist that doesn't exist
+	ErrWatchlistNotFound = errors.New("watchlist not found")
+	// ErrWatchlistExists is returned when creating a watchlist that already exists
+	ErrWatchlistExists = errors.New("watchlist already exists")
+	// ErrWatchlistFile is returned for operations on named watchlists while a
+	// watchlist file is used instead of them
+	ErrWatchlistFile = errors.New("named watchlists are unavailable while a watchlist file is set with --watchlist or STOCKTERM_WATCHLIST")
+)
+
+// WatchlistOrder is the order in which a watchlist's tickers are displayed
+type WatchlistOrder string
+
+// Watchlist orders
+const (
+	// OrderManual displays tickers in the order they are stored in
+	OrderManual WatchlistOrder = "manual"
+	// OrderAlphabetical displays tickers sorted by symbol
+	OrderAlphabetical WatchlistOrder = "alphabetical"
+	// OrderChangePercent displays tickers by percentage change, biggest gain first
+	OrderChangePercent WatchlistOrder = "change-percent"
+)
+
+// WatchlistOrders lists the valid watchlist orders
+var WatchlistOrders = []WatchlistOrder{OrderManual, OrderAlphabetical, OrderChangePercent}
+
+// orderMarketCap was an order by market capitalization, which is no longer
+// offered since the provider doesn't report it without authentication.
+// Watchlists saved with it still load, so that their order can be changed,
+// but can't be displayed; see CheckOrder.
+const orderMarketCap WatchlistOrder = "market-cap"
+
+// ParseWatchlistOrder returns the watchlist order with the given name
+func ParseWatchlistOrder(name string) (WatchlistOrder, error) {
+	for _, order := range WatchlistOrders {
+		if string(order) == name {
+			return order, nil
+		}
+	}
+
+	names := make([]string, len(WatchlistOrders))
+	for i, order := range WatchlistOrders {
+		names[i] = string(order)
+	}
+	return "", fmt.Errorf("invalid order: '%s', expected one of %s", name, strings.Join(names, ", "))
+}
+
+// Watchlist is a list of tickers with metadata about each of them
+type Watchlist struct {
+	// Version is the version of the file format
+	Version int `yaml:"version"`
+	// Order is the order in which tickers are displayed; empty means manual
+	Order WatchlistOrder `yaml:"order,omitempty"`
+	// Entries are the tickers in the watchlist, in manual order
+	Entries []WatchlistEntry `yaml:"entries"`
+}
+
+// WatchlistEntry is a ticker in a watchlist
+type WatchlistEntry struct {
+	// Symbol is the ticker symbol
+	Symbol string `yaml:"symbol"`
+	// Exchange is the exchange the ticker is listed on, if known, as named in
+	// the provider's chart data, such as NasdaqGS
+	Exchange string `yaml:"exchange,omitempty"`
+	// Notes are free-form notes about the ticker
+	Notes string `yaml:"notes,omitempty"`
+	// Tags are labels used to group tickers
+	Tags []string `yaml:"tags,omitempty"`
+	// AddedAt is when the ticker was added; zero if unknown
+	AddedAt time.Time `yaml:"added_at,omitempty"`
+	// TargetPrice is the price the ticker is expected to reach; zero if unset
+	TargetPrice float64 `yaml:"target_price,omitempty"`
+	// StopPrice is the price at which to sell the ticker; zero if unset
+	StopPrice float64 `yaml:"stop_price,omitempty"`
+}
+
+// CheckOrder returns an error if the watchlist's order is no longer supported,
+// explaining how to choose another one
+func (w *Watchlist) CheckOrder() error {
+	if w.Order == orderMarketCap {
+		return fmt.Errorf("watchlist order '%s' is no longer supported, since market capitalizations are unavailable: choose another with 'stockterm watchlist order <order>'", w.Order)
+	}
+	return nil
+}
+
+// Symbols returns the symbols of the watchlist's entries, in order
+func (w *Watchlist) Symbols() []string {
+	symbols := make([]string, 0, len(w.Entries))
+	for _, entry := range w.Entries {
+		symbols = append(symbols, entry.Symbol)
+	}
+	return symbols
+}
+
+// Find returns the index of the entry for sym, or -1 if there is none.
+// Symbols written in different notations, such as BRK.B and BRK-B, match.
+func (w *Watchlist) Find(sym string) int {
+	for i, entry := range w.Entries {
+		if symbol.Equal(entry.Symbol, sym) {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoadWatchlist loads the named watchlist from its file. An empty name means
+// the watchlist in use. Files holding comma-separated tickers, as written
+// before entries had metadata, are read as well.
+func (c *Config) LoadWatchlist(name string) (*Watchlist, error) {
+	path, err := c.watchlistFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return readWatchlistFile(path)
+}
+
+// SaveWatchlist saves the named watchlist to its file. An empty name means the
+// watchlist in use. Callers that load, modify and save a watchlist should hold
+// the lock returned by LockWatchlist throughout.
+func (c *Config) SaveWatchlist(name string, watchlist *Watchlist) error {
+	path, err := c.watchlistFile(name)
+	if err != nil {
+		return err
+	}
+
+	return writeWatchlistFile(path, watchlist)
+}
+
+// LockWatchlist acquires an exclusive lock on the named watchlist, blocking
+// until other processes modifying it release theirs. An empty name means the
+// watchlist in use. The lock is held on a separate lock file, since saving
+// replaces the watchlist file.
+func (c *Config) LockWatchlist(name string) (*fsutil.Lock, error) {
+	path, err := c.watchlistFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return fsutil.LockFile(path + ".lock")
+}
+
+// withWatchlistLock runs fn while holding the lock on the watchlist file at
+// path, the same lock LockWatchlist acquires
+func withWatchlistLock(path string, fn func() error) (err error) {
+	lock, err := fsutil.LockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := lock.Unlock(); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to unlock watchlist: %w", unlockErr)
+		}
+	}()
+
+	return fn()
+}
+
+// Watchlists returns the names of all watchlists, sorted
+func (c *Config) Watchlists() ([]string, error) {
+	if c.WatchlistPath != "" {
+		return nil, ErrWatchlistFile
+	}
+
+	entries, err := os.ReadDir(c.WatchlistDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read watchlist directory: %w", err)
+	}
+
+	// The default watchlist exists even before anything is saved to it
+	names := []string{DefaultWatchlist}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), watchlistExt)
+		if !ok || entry.IsDir() || !validWatchlistName.MatchString(name) || name == DefaultWatchlist {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// CurrentWatchlist returns the name of the watchlist in use
+func (c *Config) CurrentWatchlist() (string, error) {
+	if c.WatchlistPath != "" {
+		return "", ErrWatchlistFile
+	}
+
+	content, err := os.ReadFile(filepath.Join(c.WatchlistDir, currentWatchlistFile))
+	if os.IsNotExist(err) {
+		return DefaultWatchlist, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read current watchlist: %w", err)
+	}
+
+	// Fall back to the default watchlist if the one in use was removed
+	name := strings.TrimSpace(string(content))
+	if !c.watchlistExists(name) {
+		return DefaultWatchlist, nil
+	}
+
+	return name, nil
+}
+
+// UseWatchlist selects the watchlist used when no name is given
+func (c *Config) UseWatchlist(name string) error {
+	if err := c.checkWatchlist(name, true); err != nil {
+		return err
+	}
+
+	return c.setCurrentWatchlist(name)
+}
+
+// CreateWatchlist creates an empty watchlist
+func (c *Config) CreateWatchlist(name string) error {
+	if err := c.checkWatchlist(name, false); err != nil {
+		return err
+	}
+
+	if err := fsutil.WriteFile(c.namedWatchlistFile(name), nil, 0644); err != nil {
+		return fmt.Errorf("failed to create watchlist: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWatchlist deletes a watchlist. The watchlist in use and the default
+// watchlist, which always exists, can't be deleted. Modifications in progress
+// finish first, and those waiting for the lock then find the watchlist gone.
+func (c *Config) DeleteWatchlist(name string) error {
+	if err := c.checkWatchlist(name, true); err != nil {
+		return err
+	}
+	if name == DefaultWatchlist {
+		return fmt.Errorf("cannot delete the %s watchlist", DefaultWatchlist)
+	}
+
+	path := c.namedWatchlistFile(name)
+	return withWatchlistLock(path, func() error {
+		current, err := c.CurrentWatchlist()
+		if err != nil {
+			return err
+		}
+		if name == current {
+			return fmt.Errorf("cannot delete watchlist %s because it is in use", name)
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete watchlist: %w", err)
+		}
+		_ = os.Remove(path + ".lock")
+
+		return nil
+	})
+}
+
+// RenameWatchlist renames a watchlist, keeping it in use if it was.
+// Modifications in progress finish first, and those waiting for the lock then
+// find the watchlist gone.
+func (c *Config) RenameWatchlist(oldName, newName string) error {
+	if err := c.checkWatchlist(oldName, true); err != nil {
+		return err
+	}
+	if err := c.checkWatchlist(newName, false); err != nil {
+		return err
+	}
+
+	oldPath := c.namedWatchlistFile(oldName)
+	return withWatchlistLock(oldPath, func() error {
+		current, err := c.CurrentWatchlist()
+		if err != nil {
+			return err
+		}
+
+		// The default watchlist may not have a file yet
+		if _, err := os.Stat(oldPath); os.IsNotExist(err) {
+			err = c.CreateWatchlist(newName)
+		} else {
+			err = os.Rename(oldPath, c.namedWatchlistFile(newName))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to rename watchlist: %w", err)
+		}
+		_ = os.Remove(oldPath + ".lock")
+
+		if oldName == current {
+			return c.setCurrentWatchlist(newName)
+		}
+
+		return nil
+	})
+}
+
+// MigrateWatchlist upgrades watchlist files written by earlier versions. The
+// single watchlist.txt file kept in the data directory before watchlists were
+// named becomes the default watchlist, and named watchlists holding
+// comma-separated tickers are converted to the current format. Each file is
+// upgraded while holding the lock of the watchlist it becomes.
+func (c *Config) MigrateWatchlist() error {
+	if c.WatchlistPath != "" {
+		return nil
+	}
+
+	upgrades := map[string]string{
+		filepath.Join(c.DataDir, "watchlist.txt"): c.namedWatchlistFile(DefaultWatchlist),
+	}
+
+	legacyFiles, err := filepath.Glob(filepath.Join(c.WatchlistDir, "*"+legacyWatchlistExt))
+	if err != nil {
+		return fmt.Errorf("failed to migrate watchlists: %w", err)
+	}
+	for _, legacyFile := range legacyFiles {
+		name := strings.TrimSuffix(filepath.Base(legacyFile), legacyWatchlistExt)
+		if validWatchlistName.MatchString(name) {
+			upgrades[legacyFile] = c.namedWatchlistFile(name)
+		}
+	}
+
+	var errs []error
+	for from, to := range upgrades {
+		if err := upgradeWatchlistFile(from, to); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to migrate watchlists: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateFromLegacy migrates the watchlist from the legacy location into the
+// watchlist in use
+func (c *Config) MigrateFromLegacy(legacyPath string) error {
+	// Check if the legacy file exists
+	if _, err := os.Stat(legacyPath); os.IsNotExist(err) {
+		// Legacy file doesn't exist, nothing to migrate
+		return nil
+	}
+
+	path, err := c.watchlistFile("")
+	if err != nil {
+		return err
+	}
+
+	// Check if the new watchlist file already exists
+	if _, err := os.Stat(path); err == nil {
+		// New watchlist file already exists, don't overwrite it
+		return nil
+	}
+
+	// Read the legacy file
+	watchlist, err := readWatchlistFile(legacyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read legacy watchlist file: %w", err)
+	}
+
+	// Write the watchlist to the new file
+	return writeWatchlistFile(path, watchlist)
+}
+
+// readWatchlistFile reads a watchlist file in either format. A missing file is an empty watchlist.
+func readWatchlistFile(path string) (*Watchlist, error) {
+	// Read the watchlist file
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Watchlist{Version: WatchlistVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watchlist file: %w", err)
+	}
+
+	watchlist, err := parseWatchlist(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid watchlist file %s: %w", path, err)
+	}
+
+	return watchlist, nil
+}
+
+// parseWatchlist parses the contents of a watchlist file. Files holding a
+// YAML mapping are in the current format; anything else is read as
+// comma-separated tickers.
+func parseWatchlist(content []byte) (*Watchlist, error) {
+	watchlist := &Watchlist{Version: WatchlistVersion}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err == nil && len(root.Content) > 0 && root.Content[0].Kind == yaml.MappingNode {
+		if err := root.Decode(watchlist); err != nil {
+			return nil, err
+		}
+		if watchlist.Version > WatchlistVersion {
+			return nil, fmt.Errorf("unsupported version %d, expected at most %d", watchlist.Version, WatchlistVersion)
+		}
+		if watchlist.Order != "" && watchlist.Order != orderMarketCap {
+			if _, err := ParseWatchlistOrder(string(watchlist.Order)); err != nil {
+				return nil, err
+			}
+		}
+		return watchlist, nil
+	}
+
+	// Split the content by "," and remove leading and trailing whitespace from each ticker
+	for _, item := range strings.Split(string(content), ",") {
+		if symbol := strings.TrimSpace(item); symbol != "" {
+			watchlist.Entries = append(watchlist.Entries, WatchlistEntry{Symbol: symbol})
+		}
+	}
+
+	return watchlist, nil
+}
+
+// writeWatchlistFile writes a watchlist file in the current format
+func writeWatchlistFile(path string, watchlist *Watchlist) error {
+	watchlist.Version = WatchlistVersion
+	if watchlist.Entries == nil {
+		watchlist.Entries = []WatchlistEntry{}
+	}
+
+	content, err := encodeYAML(watchlist)
+	if err != nil {
+		return fmt.Errorf("failed to encode watchlist: %w", err)
+	}
+
+	// Replace the file atomically, so a crash never leaves a truncated watchlist
+	if err := fsutil.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write watchlist file: %w", err)
+	}
+
+	return nil
+}
+
+// upgradeWatchlistFile converts the watchlist file at from to the current
+// format, writes it to to and removes from. It does nothing if from doesn't
+// exist or to already does. The lock on to is held throughout, so that
+// concurrent commands don't both upgrade the file or modify it meanwhile.
+func upgradeWatchlistFile(from, to string) error {
+	if _, err := os.Stat(from); os.IsNotExist(err) {
+		return nil
+	}
+
+	return withWatchlistLock(to, func() error {
+		// Another process may have upgraded the file while we waited for the lock
+		if _, err := os.Stat(from); os.IsNotExist(err) {
+			return nil
+		}
+		if _, err := os.Stat(to); err == nil {
+			return nil
+		}
+
+		watchlist, err := readWatchlistFile(from)
+		if err != nil {
+			return err
+		}
+		if err := writeWatchlistFile(to, watchlist); err != nil {
+			return err
+		}
+
+		return os.Remove(from)
+	})
+}
+
+// watchlistFile returns the path of the named watchlist's file. An empty name
+// means the watchlist in use. Names can't be given while a watchlist file is set.
+func (c *Config) watchlistFile(name string) (string, error) {
+	if c.WatchlistPath != "" {
+		if name != "" {
+			return "", ErrWatchlistFile
+		}
+		return c.WatchlistPath, nil
+	}
+
+	if name == "" {
+		current, err := c.CurrentWatchlist()
+		if err != nil {
+			return "", err
+		}
+		return c.namedWatchlistFile(current), nil
+	}
+
+	if err := c.checkWatchlist(name, true); err != nil {
+		return "", err
+	}
+
+	return c.namedWatchlistFile(name), nil
+}
+
+// namedWatchlistFile returns the path of the file of the named watchlist
+func (c *Config) namedWatchlistFile(name string) string {
+	return filepath.Join(c.WatchlistDir, name+watchlistExt)
+}
+
+// watchlistExists reports whether the named watchlist exists
+func (c *Config) watchlistExists(name string) bool {
+	if name == DefaultWatchlist {
+		return true
+	}
+	if !validWatchlistName.MatchString(name) {
+		return false
+	}
+
+	_, err := os.Stat(c.namedWatchlistFile(name))
+	return err == nil
+}
+
+// checkWatchlist returns an error if named watchlists are unavailable, name is
+// invalid, or the watchlist's existence doesn't match exists
+func (c *Config) checkWatchlist(name string, exists bool) error {
+	if c.WatchlistPath != "" {
+		return ErrWatchlistFile
+	}
+	if !validWatchlistName.MatchString(name) {
+		return fmt.Errorf("invalid watchlist name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+
+	switch found := c.watchlistExists(name); {
+	case exists && !found:
+		return fmt.Errorf("%w: %s", ErrWatchlistNotFound, name)
+	case !exists && found:
+		return fmt.Errorf("%w: %s", ErrWatchlistExists, name)
+	}
+
+	return nil
+}
+
+// setCurrentWatchlist records the name of the watchlist in use
+func (c *Config) setCurrentWatchlist(name string) error {
+	if err := fsutil.WriteFile(filepath.Join(c.WatchlistDir, currentWatchlistFile), []byte(name+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to select watchlist: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestConfig returns a default configuration keeping its data in a temporary directory
+func newTestConfig(t *testing.T) *Config {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.SetDataDir(t.TempDir())
+	return cfg
+}
+
+// writeTestFile writes content to path, creating its directory
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertSymbols checks the symbols of the named watchlist
+func assertSymbols(t *testing.T, cfg *Config, name string, want []string) {
+	t.Helper()
+
+	watchlist, err := cfg.LoadWatchlist(name)
+	if err != nil {
+		t.Fatalf("LoadWatchlist(%q): %v", name, err)
+	}
+	if got := watchlist.Symbols(); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s symbols = %q, want %q", name, got, want)
+	}
+}
+
+func TestMigrateWatchlistMovesWatchlistTxt(t *testing.T) {
+	cfg := newTestConfig(t)
+	legacyPath := filepath.Join(cfg.DataDir, "watchlist.txt")
+	writeTestFile(t, legacyPath, "AAPL, MSFT,\n")
+
+	if err := cfg.MigrateWatchlist(); err != nil {
+		t.Fatalf("MigrateWatchlist: %v", err)
+	}
+
+	assertSymbols(t, cfg, DefaultWatchlist, []string{"AAPL", "MSFT"})
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("watchlist.txt still exists after migrating: %v", err)
+	}
+	content, err := os.ReadFile(cfg.namedWatchlistFile(DefaultWatchlist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "version: 1\n") {
+		t.Errorf("default.yaml is not in the current format:\n%s", content)
+	}
+}
+
+func TestMigrateWatchlistKeepsExistingDefault(t *testing.T) {
+	cfg := newTestConfig(t)
+	legacyPath := filepath.Join(cfg.DataDir, "watchlist.txt")
+	writeTestFile(t, legacyPath, "AAPL")
+	writeTestFile(t, cfg.namedWatchlistFile(DefaultWatchlist), "version: 1\nentries:\n  - symbol: TSLA\n")
+
+	if err := cfg.MigrateWatchlist(); err != nil {
+		t.Fatalf("MigrateWatchlist: %v", err)
+	}
+
+	assertSymbols(t, cfg, DefaultWatchlist, []string{"TSLA"})
+	if _, err := os.Stat(legacyPath); err != nil {
+		t.Errorf("watchlist.txt was removed without being migrated: %v", err)
+	}
+}
+
+func TestMigrateWatchlistUpgradesLegacyLists(t *testing.T) {
+	cfg := newTestConfig(t)
+	legacyPath := filepath.Join(cfg.WatchlistDir, "tech.txt")
+	writeTestFile(t, legacyPath, "NVDA,AMD")
+	// Files whose names aren't valid watchlist names are left alone
+	invalidPath := filepath.Join(cfg.WatchlistDir, ".hidden.txt")
+	writeTestFile(t, invalidPath, "AAPL")
+
+	if err := cfg.MigrateWatchlist(); err != nil {
+		t.Fatalf("MigrateWatchlist: %v", err)
+	}
+
+	assertSymbols(t, cfg, "tech", []string{"NVDA", "AMD"})
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("tech.txt still exists after migrating: %v", err)
+	}
+	if _, err := os.Stat(invalidPath); err != nil {
+		t.Errorf(".hidden.txt was migrated: %v", err)
+	}
+
+	names, err := cfg.Watchlists()
+	if err != nil {
+		t.Fatalf("Watchlists: %v", err)
+	}
+	if want := []string{"default", "tech"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("watchlists = %q, want %q", names, want)
+	}
+}
+
+func TestMigrateWatchlistWaitsForLock(t *testing.T) {
+	cfg := newTestConfig(t)
+	writeTestFile(t, filepath.Join(cfg.DataDir, "watchlist.txt"), "AAPL")
+
+	lock, err := cfg.LockWatchlist(DefaultWatchlist)
+	if err != nil {
+		t.Fatalf("LockWatchlist: %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- cfg.MigrateWatchlist() }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("MigrateWatchlist returned while the watchlist was locked: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("MigrateWatchlist: %v", err)
+	}
+	assertSymbols(t, cfg, DefaultWatchlist, []string{"AAPL"})
+}
+
+func TestWatchlistRenameDeleteUse(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	if err := cfg.CreateWatchlist("tech"); err != nil {
+		t.Fatalf("CreateWatchlist: %v", err)
+	}
+	if err := cfg.CreateWatchlist("tech"); !errors.Is(err, ErrWatchlistExists) {
+		t.Errorf("CreateWatchlist of an existing watchlist: err = %v, want ErrWatchlistExists", err)
+	}
+	if err := cfg.SaveWatchlist("tech", &Watchlist{Entries: []WatchlistEntry{{Symbol: "NVDA"}}}); err != nil {
+		t.Fatalf("SaveWatchlist: %v", err)
+	}
+
+	if err := cfg.UseWatchlist("tech"); err != nil {
+		t.Fatalf("UseWatchlist: %v", err)
+	}
+	if current, err := cfg.CurrentWatchlist(); err != nil || current != "tech" {
+		t.Errorf("CurrentWatchlist = %q, %v, want tech", current, err)
+	}
+	assertSymbols(t, cfg, "", []string{"NVDA"})
+
+	// Renaming the watchlist in use keeps it in use
+	if err := cfg.RenameWatchlist("tech", "chips"); err != nil {
+		t.Fatalf("RenameWatchlist: %v", err)
+	}
+	if current, err := cfg.CurrentWatchlist(); err != nil || current != "chips" {
+		t.Errorf("CurrentWatchlist = %q, %v after renaming, want chips", current, err)
+	}
+	assertSymbols(t, cfg, "chips", []string{"NVDA"})
+	if _, err := cfg.LoadWatchlist("tech"); !errors.Is(err, ErrWatchlistNotFound) {
+		t.Errorf("LoadWatchlist of the old name: err = %v, want ErrWatchlistNotFound", err)
+	}
+
+	if err := cfg.UseWatchlist(DefaultWatchlist); err != nil {
+		t.Fatalf("UseWatchlist: %v", err)
+	}
+	if err := cfg.DeleteWatchlist("chips"); err != nil {
+		t.Fatalf("DeleteWatchlist: %v", err)
+	}
+	if err := cfg.DeleteWatchlist("chips"); !errors.Is(err, ErrWatchlistNotFound) {
+		t.Errorf("DeleteWatchlist of a deleted watchlist: err = %v, want ErrWatchlistNotFound", err)
+	}
+
+	names, err := cfg.Watchlists()
+	if err != nil {
+		t.Fatalf("Watchlists: %v", err)
+	}
+	if want := []string{DefaultWatchlist}; !reflect.DeepEqual(names, want) {
+		t.Errorf("watchlists = %q, want %q", names, want)
+	}
+	if _, err := os.Stat(cfg.namedWatchlistFile("chips") + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file still exists after deleting: %v", err)
+	}
+}
+
+func TestRenameDefaultWatchlistWithoutFile(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	if err := cfg.RenameWatchlist(DefaultWatchlist, "main"); err != nil {
+		t.Fatalf("RenameWatchlist: %v", err)
+	}
+	if current, err := cfg.CurrentWatchlist(); err != nil || current != "main" {
+		t.Errorf("CurrentWatchlist = %q, %v, want main", current, err)
+	}
+	assertSymbols(t, cfg, "main", []string{})
+}
+
+func TestDeleteWatchlistGuards(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := cfg.CreateWatchlist("tech"); err != nil {
+		t.Fatalf("CreateWatchlist: %v", err)
+	}
+	if err := cfg.UseWatchlist("tech"); err != nil {
+		t.Fatalf("UseWatchlist: %v", err)
+	}
+
+	err := cfg.DeleteWatchlist("tech")
+	if err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("DeleteWatchlist of the watchlist in use: err = %v, want an in use error", err)
+	}
+	if !cfg.watchlistExists("tech") {
+		t.Error("the watchlist in use was deleted")
+	}
+
+	if err := cfg.DeleteWatchlist(DefaultWatchlist); err == nil {
+		t.Error("DeleteWatchlist of the default watchlist succeeded")
+	}
+}
+
+func TestNamedWatchlistsUnavailableWithWatchlistFile(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.WatchlistPath = filepath.Join(cfg.DataDir, "list.yaml")
+
+	if err := cfg.CreateWatchlist("tech"); !errors.Is(err, ErrWatchlistFile) {
+		t.Errorf("CreateWatchlist: err = %v, want ErrWatchlistFile", err)
+	}
+	if err := cfg.DeleteWatchlist("tech"); !errors.Is(err, ErrWatchlistFile) {
+		t.Errorf("DeleteWatchlist: err = %v, want ErrWatchlistFile", err)
+	}
+	if _, err := cfg.LoadWatchlist("tech"); !errors.Is(err, ErrWatchlistFile) {
+		t.Errorf("LoadWatchlist: err = %v, want ErrWatchlistFile", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package fsutil provides crash-safe file writes and advisory file locks
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to the file at path atomically. The data is written to
+// a temporary file in the same directory, flushed to disk and renamed over
+// path, so readers and crashes never observe a partially written file. The
+// directory is created if it doesn't exist.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	// Removing the temporary file fails harmlessly once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to flush temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	// Flush the directory, so the rename itself survives a crash
+	return syncDir(dir)
+}
+
+// Lock is an advisory lock held on a lock file
+type Lock struct {
+	file *os.File
+}
+
+// LockFile acquires an exclusive advisory lock on the file at path, creating
+// it if needed, and blocks until the lock is available. Other processes only
+// respect the lock if they lock the same file. Since WriteFile replaces files,
+// lock a separate file, such as path + ".lock", rather than the file being written.
+func LockFile(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lock(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	// Closing the file releases the lock even if unlocking fails
+	unlockErr := unlock(l.file)
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	return unlockErr
+}
@@ -0,0 +1,20 @@
+//go:build !unix && !windows
+
+package fsutil
+
+import "os"
+
+// lock does nothing on platforms without file locking
+func lock(file *os.File) error {
+	return nil
+}
+
+// unlock does nothing on platforms without file locking
+func unlock(file *os.File) error {
+	return nil
+}
+
+// syncDir does nothing on platforms without directory syncing
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lock acquires an exclusive lock on the first byte of file
+func lock(file *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &overlapped)
+}
+
+// unlock releases the lock on the first byte of file
+func unlock(file *os.File) error {
+	var overlapped windows.Overlapped
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, &overlapped)
+}
+
+// syncDir does nothing, since Windows can't flush directories and renames are
+// already durable on NTFS
+func syncDir(dir string) error {
+	return nil
+}
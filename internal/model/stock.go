@@ -1,5 +1,10 @@
 package model
 
+import (
+	"math"
+	"strings"
+)
+
 // ChartResponse represents the response from Yahoo Finance API
 type ChartResponse struct {
 	Chart struct {
@@ -8,6 +13,7 @@ type ChartResponse struct {
 				Currency             string  `json:"currency"`
 				Symbol               string  `json:"symbol"`
 				ExchangeName         string  `json:"exchangeName"`
+				FullExchangeName     string  `json:"fullExchangeName"`
 				InstrumentType       string  `json:"instrumentType"`
 				FirstTradeDate       int     `json:"firstTradeDate"`
 				RegularMarketTime    int     `json:"regularMarketTime"`
@@ -16,6 +22,11 @@ type ChartResponse struct {
 				Timezone             string  `json:"timezone"`
 				ExchangeTimezoneName string  `json:"exchangeTimezoneName"`
 				RegularMarketPrice   float64 `json:"regularMarketPrice"`
+				RegularMarketDayHigh float64 `json:"regularMarketDayHigh"`
+				RegularMarketDayLow  float64 `json:"regularMarketDayLow"`
+				RegularMarketVolume  int64   `json:"regularMarketVolume"`
+				FiftyTwoWeekHigh     float64 `json:"fiftyTwoWeekHigh"`
+				FiftyTwoWeekLow      float64 `json:"fiftyTwoWeekLow"`
 				ChartPreviousClose   float64 `json:"chartPreviousClose"`
 				PreviousClose        float64 `json:"previousClose"`
 				Scale                int     `json:"scale"`
@@ -33,16 +44,40 @@ type ChartResponse struct {
 			Timestamp  []int64 `json:"timestamp"`
 			Indicators struct {
 				Quote []struct {
-					Close []float64 `json:"close"`
-					Low   []float64 `json:"low"`
-					High  []float64 `json:"high"`
-					Open  []float64 `json:"open"`
+					Close  []float64 `json:"close"`
+					Low    []float64 `json:"low"`
+					High   []float64 `json:"high"`
+					Open   []float64 `json:"open"`
+					Volume []int64   `json:"volume"`
 				} `json:"quote"`
 			} `json:"indicators"`
 		} `json:"result"`
+		Error *ChartError `json:"error"`
 	} `json:"chart"`
 }
 
+// ChartError represents an error reported by the Yahoo Finance API
+type ChartError struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// SearchResponse represents a response from the Yahoo Finance search endpoint
+type SearchResponse struct {
+	Quotes []SearchResult `json:"quotes"`
+}
+
+// SearchResult represents a ticker found by a search
+type SearchResult struct {
+	Symbol          string `json:"symbol"`
+	ShortName       string `json:"shortname"`
+	LongName        string `json:"longname"`
+	Exchange        string `json:"exchange"`
+	ExchangeDisplay string `json:"exchDisp"`
+	QuoteType       string `json:"quoteType"`
+	TypeDisplay     string `json:"typeDisp"`
+}
+
 // TradingPeriod represents a trading period in the market
 type TradingPeriod struct {
 	Timezone  string `json:"timezone"`
@@ -51,7 +86,8 @@ type TradingPeriod struct {
 	GMToffset int    `json:"gmtoffset"`
 }
 
-// StockData represents the essential stock data for display
+// StockData represents the essential stock data for display. Change and
+// ChangePercent are NaN if the previous close is unknown.
 type StockData struct {
 	Ticker        string
 	LastPrice     float64
@@ -59,6 +95,21 @@ type StockData struct {
 	ChangePercent float64
 	PreviousClose float64
 	Currency      string
+
+	// Open, DayHigh, DayLow and Volume describe the last trading day, and are
+	// zero if the chart doesn't cover it
+	Open    float64
+	DayHigh float64
+	DayLow  float64
+	Volume  int64
+
+	// FiftyTwoWeekHigh and FiftyTwoWeekLow are zero if unknown
+	FiftyTwoWeekHigh float64
+	FiftyTwoWeekLow  float64
+
+	// AverageVolume is the average daily volume over three months, which is
+	// not part of the chart; see AverageVolume. It is zero if unknown.
+	AverageVolume int64
 }
 
 // NewStockData creates a StockData instance from a ChartResponse
@@ -67,16 +118,108 @@ func NewStockData(response ChartResponse) StockData {
 		return StockData{}
 	}
 
-	meta := response.Chart.Result[0].Meta
-	diff := meta.RegularMarketPrice - meta.PreviousClose
-	changePercent := diff / meta.PreviousClose * 100
-
-	return StockData{
-		Ticker:        meta.Symbol,
-		LastPrice:     meta.RegularMarketPrice,
-		Change:        diff,
-		ChangePercent: changePercent,
-		PreviousClose: meta.PreviousClose,
-		Currency:      meta.Currency,
+	result := response.Chart.Result[0]
+	meta := result.Meta
+
+	// Charts of ranges longer than a day may only hold the close before the
+	// chart's start. The change is unknown, and NaN, without a previous close.
+	previousClose := meta.PreviousClose
+	if previousClose == 0 {
+		previousClose = meta.ChartPreviousClose
+	}
+	diff, changePercent := math.NaN(), math.NaN()
+	if previousClose != 0 {
+		diff = meta.RegularMarketPrice - previousClose
+		changePercent = diff / previousClose * 100
+	}
+
+	data := StockData{
+		Ticker:           meta.Symbol,
+		LastPrice:        meta.RegularMarketPrice,
+		Change:           diff,
+		ChangePercent:    changePercent,
+		PreviousClose:    previousClose,
+		Currency:         meta.Currency,
+		FiftyTwoWeekHigh: meta.FiftyTwoWeekHigh,
+		FiftyTwoWeekLow:  meta.FiftyTwoWeekLow,
+	}
+
+	// Take the day's figures from the series if its points are no longer than a day
+	if len(result.Indicators.Quote) > 0 && dailyOrFiner(meta.DataGranularity) {
+		quote := result.Indicators.Quote[0]
+		data.addLastDay(result.Timestamp, meta.GMTOffset, quote.Open, quote.High, quote.Low, quote.Volume)
+	}
+
+	// The meta holds the whole day's figures, even if the series is cut short
+	if meta.RegularMarketDayHigh > 0 {
+		data.DayHigh = meta.RegularMarketDayHigh
+	}
+	if meta.RegularMarketDayLow > 0 {
+		data.DayLow = meta.RegularMarketDayLow
+	}
+	if meta.RegularMarketVolume > 0 {
+		data.Volume = meta.RegularMarketVolume
 	}
+
+	return data
+}
+
+// AverageVolume returns the mean daily volume of a chart of daily points, such
+// as a 3mo chart at a 1d interval, or zero if the chart holds no daily volumes.
+// Missing points are zero and are skipped.
+func AverageVolume(response ChartResponse) int64 {
+	if len(response.Chart.Result) == 0 {
+		return 0
+	}
+
+	result := response.Chart.Result[0]
+	if result.Meta.DataGranularity != "1d" || len(result.Indicators.Quote) == 0 {
+		return 0
+	}
+
+	var total, days int64
+	for _, volume := range result.Indicators.Quote[0].Volume {
+		if volume > 0 {
+			total += volume
+			days++
+		}
+	}
+	if days == 0 {
+		return 0
+	}
+	return total / days
+}
+
+// addLastDay sets the open, high, low and volume from the points of the
+// series that fall on the same day as the last one, in the exchange's time zone.
+// Missing points are zero and are skipped.
+func (d *StockData) addLastDay(timestamps []int64, gmtOffset int, open, high, low []float64, volume []int64) {
+	if len(timestamps) == 0 {
+		return
+	}
+
+	day := func(i int) int64 {
+		return (timestamps[i] + int64(gmtOffset)) / (24 * 60 * 60)
+	}
+
+	last := len(timestamps) - 1
+	for i := last; i >= 0 && day(i) == day(last); i-- {
+		if i < len(open) && open[i] > 0 {
+			d.Open = open[i]
+		}
+		if i < len(high) && high[i] > d.DayHigh {
+			d.DayHigh = high[i]
+		}
+		if i < len(low) && low[i] > 0 && (d.DayLow == 0 || low[i] < d.DayLow) {
+			d.DayLow = low[i]
+		}
+		if i < len(volume) {
+			d.Volume += volume[i]
+		}
+	}
+}
+
+// dailyOrFiner reports whether a data granularity, such as 5m or 1d, is at most a day
+func dailyOrFiner(granularity string) bool {
+	return granularity == "1d" || strings.HasSuffix(granularity, "m") || strings.HasSuffix(granularity, "h")
 }
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestNewStockDataPreviousClose(t *testing.T) {
+	tests := []struct {
+		name          string
+		meta          string
+		previousClose float64
+		changePercent float64
+	}{
+		{"previous close", `{"regularMarketPrice":110,"previousClose":100,"chartPreviousClose":50}`, 100, 10},
+		{"chart previous close", `{"regularMarketPrice":110,"chartPreviousClose":50}`, 50, 120},
+		{"unknown", `{"regularMarketPrice":110}`, 0, math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response ChartResponse
+			if err := json.Unmarshal([]byte(`{"chart":{"result":[{"meta":`+tt.meta+`}]}}`), &response); err != nil {
+				t.Fatal(err)
+			}
+
+			data := NewStockData(response)
+			if data.PreviousClose != tt.previousClose {
+				t.Errorf("PreviousClose = %v, want %v", data.PreviousClose, tt.previousClose)
+			}
+			if math.IsNaN(tt.changePercent) {
+				if !math.IsNaN(data.Change) || !math.IsNaN(data.ChangePercent) {
+					t.Errorf("Change, ChangePercent = %v, %v, want NaN", data.Change, data.ChangePercent)
+				}
+				return
+			}
+			if math.Abs(data.ChangePercent-tt.changePercent) > 1e-9 {
+				t.Errorf("ChangePercent = %v, want %v", data.ChangePercent, tt.changePercent)
+			}
+		})
+	}
+}
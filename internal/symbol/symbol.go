@@ -0,0 +1,183 @@
+// Package symbol converts the notations tickers are commonly written in to
+// the canonical form used by Yahoo Finance.
+//
+// Supported notations are share classes separated by a dot, slash or space
+// (BRK.B, BRK/B, BRK B), also before an exchange suffix (BAM.A.TO), Bloomberg exchange codes (SAP GY, VOD LN Equity),
+// exchange prefixes (XETRA:SAP, LON:VOD) and cashtags ($AAPL). Symbols
+// already in Yahoo's form, such as BRK-B, SAP.DE, ^GSPC or EURUSD=X, are
+// kept as they are.
+package symbol
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// bloombergCodes maps Bloomberg exchange codes to Yahoo Finance suffixes
+var bloombergCodes = map[string]string{
+	"US": "", "UN": "", "UW": "", "UQ": "", "UA": "", "UP": "", "UR": "", "UV": "",
+	"GY": ".DE", "GR": ".DE", "GF": ".F",
+	"LN": ".L",
+	"FP": ".PA",
+	"NA": ".AS",
+	"BB": ".BR",
+	"SM": ".MC",
+	"IM": ".MI",
+	"SW": ".SW", "SE": ".SW",
+	"AV": ".VI",
+	"PL": ".LS",
+	"ID": ".IR",
+	"SS": ".ST",
+	"DC": ".CO",
+	"NO": ".OL",
+	"FH": ".HE",
+	"CN": ".TO", "CT": ".TO", "CV": ".V",
+	"JP": ".T", "JT": ".T",
+	"HK": ".HK",
+	"AU": ".AX", "AT": ".AX",
+	"NZ": ".NZ",
+	"SP": ".SI",
+	"KS": ".KS",
+	"TT": ".TW",
+	"IN": ".NS", "IB": ".BO",
+}
+
+// exchangePrefixes maps the exchange prefixes used by sites such as Google
+// Finance and TradingView to Yahoo Finance suffixes
+var exchangePrefixes = map[string]string{
+	"NASDAQ": "", "NYSE": "", "AMEX": "", "NYSEARCA": "", "NYSEAMERICAN": "", "ARCA": "", "BATS": "", "OTC": "",
+	"XETRA": ".DE", "XETR": ".DE", "ETR": ".DE",
+	"FRA": ".F",
+	"LON": ".L", "LSE": ".L",
+	"EPA": ".PA",
+	"AMS": ".AS",
+	"EBR": ".BR",
+	"BME": ".MC",
+	"BIT": ".MI",
+	"SWX": ".SW", "SIX": ".SW",
+	"VIE": ".VI",
+	"ELI": ".LS",
+	"STO": ".ST",
+	"CPH": ".CO",
+	"OSL": ".OL",
+	"HEL": ".HE",
+	"TSE": ".TO", "TSX": ".TO", "CVE": ".V", "TSXV": ".V",
+	"TYO": ".T",
+	"HKG": ".HK", "HKEX": ".HK",
+	"ASX": ".AX",
+	"NZE": ".NZ",
+	"SGX": ".SI",
+	"KRX": ".KS",
+	"TPE": ".TW",
+	"NSE": ".NS", "BOM": ".BO",
+}
+
+// yahooSuffixes are the exchange suffixes Yahoo Finance appends to symbols.
+// Any other single letter after a dot is taken to be a share class.
+var yahooSuffixes = map[string]bool{
+	"DE": true, "F": true, "L": true, "PA": true, "AS": true, "BR": true,
+	"MC": true, "MI": true, "SW": true, "VI": true, "LS": true, "IR": true,
+	"ST": true, "CO": true, "OL": true, "HE": true, "TO": true, "V": true,
+	"NE": true, "T": true, "HK": true, "AX": true, "NZ": true, "SI": true,
+	"KS": true, "KQ": true, "TW": true, "NS": true, "BO": true, "SA": true,
+	"MX": true, "SS": true, "SZ": true, "JK": true, "BK": true, "KL": true,
+	"TA": true, "JO": true, "IS": true, "WA": true, "PR": true, "BD": true,
+	"AT": true, "IC": true, "SR": true, "QA": true, "CA": true, "BA": true,
+	"SN": true, "CN": true, "HM": true, "MU": true, "SG": true, "DU": true,
+	"BE": true, "VX": true,
+}
+
+// validSymbol matches symbols in Yahoo's form, including indices (^GSPC),
+// currencies (EURUSD=X) and futures (CL=F)
+var validSymbol = regexp.MustCompile(`^\^?[A-Z0-9][A-Z0-9.\-&]*(=[A-Z])?$`)
+
+// Normalize returns the canonical form of a symbol written in any of the
+// supported notations, or an error if it isn't a valid symbol
+func Normalize(s string) (string, error) {
+	sym := strings.ToUpper(strings.TrimSpace(s))
+	sym = strings.TrimPrefix(sym, "$")
+	if sym == "" {
+		return "", fmt.Errorf("ticker cannot be empty")
+	}
+
+	// Exchange prefixes, e.g. XETRA:SAP
+	if prefix, rest, ok := strings.Cut(sym, ":"); ok {
+		suffix, known := exchangePrefixes[strings.TrimSpace(prefix)]
+		if !known {
+			return "", fmt.Errorf("invalid ticker %q: unknown exchange %s", s, prefix)
+		}
+		sym = strings.TrimSpace(rest) + suffix
+	}
+
+	// Bloomberg tickers, e.g. SAP GY Equity, or share classes, e.g. BRK B
+	fields := strings.Fields(sym)
+	if len(fields) > 1 && fields[len(fields)-1] == "EQUITY" {
+		fields = fields[:len(fields)-1]
+	}
+	switch len(fields) {
+	case 1:
+		sym = fields[0]
+	case 2:
+		if suffix, known := bloombergCodes[fields[1]]; known {
+			sym = shareClass(fields[0], "/") + suffix
+		} else if len(fields[1]) == 1 {
+			sym = fields[0] + "-" + fields[1]
+		} else {
+			return "", fmt.Errorf("invalid ticker %q: unknown exchange code %s", s, fields[1])
+		}
+	case 3:
+		// Bloomberg writes share classes with a space too, e.g. BRK B US
+		suffix, known := bloombergCodes[fields[2]]
+		if !known || len(fields[1]) != 1 {
+			return "", fmt.Errorf("invalid ticker %q", s)
+		}
+		sym = fields[0] + "-" + fields[1] + suffix
+	default:
+		return "", fmt.Errorf("invalid ticker %q", s)
+	}
+
+	// A share class may come before an exchange suffix, as in BAM.A.TO
+	base, suffix := sym, ""
+	if i := strings.LastIndex(sym, "."); i > 0 && yahooSuffixes[sym[i+1:]] {
+		base, suffix = sym[:i], sym[i:]
+	}
+	base = shareClass(base, "/")
+	base = shareClass(base, ".")
+	sym = base + suffix
+
+	if !validSymbol.MatchString(sym) {
+		return "", fmt.Errorf("invalid ticker %q", s)
+	}
+
+	return sym, nil
+}
+
+// Equal reports whether two symbols are the same in different notations
+func Equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+	na, errA := Normalize(a)
+	nb, errB := Normalize(b)
+	return errA == nil && errB == nil && na == nb
+}
+
+// shareClass replaces the separator before a share class with a dash, as in
+// BRK.B or BRK/B, leaving exchange suffixes such as VOD.L alone
+func shareClass(sym, sep string) string {
+	i := strings.LastIndex(sym, sep)
+	if i <= 0 || i == len(sym)-1 {
+		return sym
+	}
+
+	base, class := sym[:i], sym[i+1:]
+	if sep == "." && yahooSuffixes[class] {
+		return sym
+	}
+	if len(class) != 1 || class[0] < 'A' || class[0] > 'Z' {
+		return sym
+	}
+
+	return base + "-" + class
+}
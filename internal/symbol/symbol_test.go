@@ -0,0 +1,93 @@
+package symbol
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		// Yahoo's own form
+		{"AAPL", "AAPL"},
+		{" aapl ", "AAPL"},
+		{"BRK-B", "BRK-B"},
+		{"VOD.L", "VOD.L"},
+		{"SAP.DE", "SAP.DE"},
+		{"^GSPC", "^GSPC"},
+		{"EURUSD=X", "EURUSD=X"},
+		{"CL=F", "CL=F"},
+
+		// Cashtags
+		{"$AAPL", "AAPL"},
+
+		// Share classes
+		{"BRK.B", "BRK-B"},
+		{"BRK/B", "BRK-B"},
+		{"BRK B", "BRK-B"},
+		{"brk.b", "BRK-B"},
+
+		// Share classes followed by an exchange suffix
+		{"BAM.A.TO", "BAM-A.TO"},
+		{"BT.A.L", "BT-A.L"},
+		{"BT/A.L", "BT-A.L"},
+
+		// Bloomberg exchange codes
+		{"SAP GY", "SAP.DE"},
+		{"VOD LN Equity", "VOD.L"},
+		{"AAPL US", "AAPL"},
+		{"BRK/B US", "BRK-B"},
+		{"BRK B US", "BRK-B"},
+		{"BT/A LN", "BT-A.L"},
+
+		// Exchange prefixes
+		{"XETRA:SAP", "SAP.DE"},
+		{"NASDAQ:AAPL", "AAPL"},
+		{"LON: VOD", "VOD.L"},
+		{"TSX:BAM.A", "BAM-A.TO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if err != nil {
+				t.Fatalf("Normalize(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+	for _, in := range []string{"", "  ", "$", "FOO:BAR", "SAP XX", "A B C D", "BRK BB US", "AA;PL"} {
+		t.Run(in, func(t *testing.T) {
+			if got, err := Normalize(in); err == nil {
+				t.Errorf("Normalize(%q) = %q, want an error", in, got)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"AAPL", "AAPL", true},
+		{"aapl", "$AAPL", true},
+		{"BRK.B", "BRK-B", true},
+		{"brk/b", "BRK B", true},
+		{"SAP GY", "XETRA:SAP", true},
+		{"BAM.A.TO", "BAM-A.TO", true},
+		{"AAPL", "MSFT", false},
+		{"SAP.DE", "SAP", false},
+		{"FOO:BAR", "BAR", false},
+	}
+
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"stockterm/internal/model"
+)
+
+// columnKind determines how the values of a column are formatted
+type columnKind int
+
+const (
+	// kindText columns hold text
+	kindText columnKind = iota
+	// kindPrice columns hold prices, shown as a dash when unknown
+	kindPrice
+	// kindChange columns hold signed changes, colored by their sign
+	kindChange
+	// kindPercent columns hold signed percentages, colored by their sign
+	kindPercent
+	// kindVolume columns hold share volumes, shown as a dash when unknown
+	kindVolume
+	// kindRange columns hold a low and a high price
+	kindRange
+)
+
+// columnDef defines a column that can be shown in the stock table
+type columnDef struct {
+	name   string
+	header string
+	kind   columnKind
+	// text returns the value of text columns
+	text func(stock model.StockData) string
+	// value returns the value of number columns, and the low of range columns
+	value func(stock model.StockData) float64
+	// high returns the high of range columns
+	high func(stock model.StockData) float64
+}
+
+// columnDefs are the columns that can be shown, in the order they are listed in help
+var columnDefs = []columnDef{
+	{name: "ticker", header: "Ticker", kind: kindText, text: func(s model.StockData) string { return s.Ticker }},
+	{name: "price", header: "Last Price", kind: kindPrice, value: func(s model.StockData) float64 { return s.LastPrice }},
+	{name: "change", header: "Change", kind: kindChange, value: func(s model.StockData) float64 { return s.Change }},
+	{name: "change_percent", header: "Change %", kind: kindPercent, value: func(s model.StockData) float64 { return s.ChangePercent }},
+	{name: "open", header: "Open", kind: kindPrice, value: func(s model.StockData) float64 { return s.Open }},
+	{name: "day_low", header: "Day Low", kind: kindPrice, value: func(s model.StockData) float64 { return s.DayLow }},
+	{name: "day_high", header: "Day High", kind: kindPrice, value: func(s model.StockData) float64 { return s.DayHigh }},
+	{name: "day_range", header: "Day Range", kind: kindRange,
+		value: func(s model.StockData) float64 { return s.DayLow },
+		high:  func(s model.StockData) float64 { return s.DayHigh }},
+	{name: "52w_low", header: "52W Low", kind: kindPrice, value: func(s model.StockData) float64 { return s.FiftyTwoWeekLow }},
+	{name: "52w_high", header: "52W High", kind: kindPrice, value: func(s model.StockData) float64 { return s.FiftyTwoWeekHigh }},
+	{name: "52w_range", header: "52W Range", kind: kindRange,
+		value: func(s model.StockData) float64 { return s.FiftyTwoWeekLow },
+		high:  func(s model.StockData) float64 { return s.FiftyTwoWeekHigh }},
+	{name: "volume", header: "Volume", kind: kindVolume, value: func(s model.StockData) float64 { return float64(s.Volume) }},
+	{name: "avg_volume", header: "Avg. Volume", kind: kindVolume, value: func(s model.StockData) float64 { return float64(s.AverageVolume) }},
+	{name: "prev_close", header: "Prev. Close", kind: kindPrice, value: func(s model.StockData) float64 { return s.PreviousClose }},
+	{name: "currency", header: "Currency", kind: kindText, text: func(s model.StockData) string { return s.Currency }},
+}
+
+// DefaultColumns are the columns shown when none are configured
+var DefaultColumns = []string{
+	"ticker", "price", "change", "change_percent", "open", "day_range",
+	"52w_range", "volume", "avg_volume", "prev_close", "currency",
+}
+
+// ColumnNames returns the names of all columns that can be shown
+func ColumnNames() []string {
+	names := make([]string, 0, len(columnDefs))
+	for _, def := range columnDefs {
+		names = append(names, def.name)
+	}
+	return names
+}
+
+// Column is a column of the stock table along with how its values are formatted
+type Column struct {
+	def *columnDef
+	// decimals is the number of decimals shown, or -1 for the column's default
+	decimals int
+	// short abbreviates large numbers, as in 1.2M or 3.4B
+	short bool
+}
+
+// Name returns the name the column is selected by
+func (c Column) Name() string {
+	return c.def.name
+}
+
+// ParseColumns parses column specs, such as "volume:short" or "price:4". A
+// spec is a column name optionally followed by a number of decimals and
+// "short", which abbreviates large numbers, each after a colon. No specs
+// means the default columns.
+func ParseColumns(specs []string) ([]Column, error) {
+	if len(specs) == 0 {
+		specs = DefaultColumns
+	}
+
+	var columns []Column
+	for _, spec := range specs {
+		column, err := parseColumn(spec)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// parseColumn parses a single column spec
+func parseColumn(spec string) (Column, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(spec)), ":")
+
+	def, err := findColumn(parts[0])
+	if err != nil {
+		return Column{}, err
+	}
+
+	column := Column{def: def, decimals: -1}
+
+	for _, option := range parts[1:] {
+		if option == "short" {
+			column.short = true
+			continue
+		}
+
+		decimals, err := strconv.Atoi(option)
+		if err != nil || decimals < 0 || decimals > 8 {
+			return Column{}, fmt.Errorf("invalid format '%s' for column %s: expected a number of decimals from 0 to 8 or 'short'", option, parts[0])
+		}
+		column.decimals = decimals
+	}
+
+	if column.def.kind == kindText && (column.short || column.decimals >= 0) {
+		return Column{}, fmt.Errorf("column %s holds text and can't be formatted", parts[0])
+	}
+
+	return column, nil
+}
+
+// findColumn returns the definition of the named column
+func findColumn(name string) (*columnDef, error) {
+	for i := range columnDefs {
+		if columnDefs[i].name == name {
+			return &columnDefs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown column '%s', expected one of %s", name, strings.Join(ColumnNames(), ", "))
+}
+
+// cell returns the formatted value of the column for a stock
+func (c Column) cell(stock model.StockData) string {
+	switch c.def.kind {
+	case kindText:
+		return c.def.text(stock)
+	case kindChange, kindPercent:
+		return c.formatSigned(c.def.value(stock))
+	case kindVolume:
+		return c.formatVolume(c.def.value(stock))
+	case kindRange:
+		low, high := c.def.value(stock), c.def.high(stock)
+		if low == 0 || high == 0 {
+			return "-"
+		}
+		return c.formatNumber(low, 2) + " - " + c.formatNumber(high, 2)
+	default:
+		price := c.def.value(stock)
+		if price == 0 {
+			return "-"
+		}
+		return c.formatNumber(price, 2)
+	}
+}
+
+// formatSigned formats a change, with a plus sign unless it is negative once
+// rounded, showing a dash if it is unknown
+func (c Column) formatSigned(value float64) string {
+	if !isFinite(value) {
+		return "-"
+	}
+	formatted := c.formatNumber(value, 2)
+	if !strings.HasPrefix(formatted, "-") {
+		return "+" + formatted
+	}
+	return formatted
+}
+
+// formatVolume formats a volume, showing a dash if it is unknown. Unless it is
+// abbreviated, it is shown with thousands separators and no decimals by default.
+func (c Column) formatVolume(volume float64) string {
+	if volume == 0 {
+		return "-"
+	}
+	if c.short {
+		return c.formatNumber(volume, 1)
+	}
+
+	decimals := c.decimals
+	if decimals < 0 {
+		decimals = 0
+	}
+	formatted := strconv.FormatFloat(volume, 'f', decimals, 64)
+	digits, fraction, _ := strings.Cut(formatted, ".")
+
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString("." + fraction)
+	}
+	return b.String()
+}
+
+// formatNumber formats a number with the column's decimals, or defaultDecimals
+// if it has none, abbreviating it if the column is short
+func (c Column) formatNumber(value float64, defaultDecimals int) string {
+	decimals := c.decimals
+	if decimals < 0 {
+		decimals = defaultDecimals
+	}
+	if !c.short {
+		return formatFloat(value, decimals)
+	}
+	return abbreviate(value, decimals)
+}
+
+// abbreviate formats a number with a suffix for thousands, millions, billions or trillions
+func abbreviate(value float64, decimals int) string {
+	suffixes := []string{"", "K", "M", "B", "T"}
+
+	i := 0
+	for i < len(suffixes)-1 && math.Abs(value) >= 1000 {
+		value /= 1000
+		i++
+	}
+
+	// Rounding may carry over into the next suffix, as in 999.96K
+	if rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'f', decimals, 64), 64); math.Abs(rounded) >= 1000 && i < len(suffixes)-1 {
+		value /= 1000
+		i++
+	}
+
+	return formatFloat(value, decimals) + suffixes[i]
+}
+
+// formatFloat formats a number with the given decimals. Numbers that round to
+// zero are shown without a sign, so that -0.004 isn't shown as "-0".
+func formatFloat(value float64, decimals int) string {
+	formatted := strconv.FormatFloat(value, 'f', decimals, 64)
+	if unsigned, ok := strings.CutPrefix(formatted, "-"); ok && strings.Trim(unsigned, "0.") == "" {
+		return unsigned
+	}
+	return formatted
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+
+	"stockterm/internal/model"
+)
+
+func TestParseColumn(t *testing.T) {
+	tests := []struct {
+		spec     string
+		name     string
+		decimals int
+		short    bool
+		valid    bool
+	}{
+		{"price", "price", -1, false, true},
+		{" Price:4 ", "price", 4, false, true},
+		{"volume:short", "volume", -1, true, true},
+		{"volume:short:2", "volume", 2, true, true},
+		{"change:0:short", "change", 0, true, true},
+		{"change_percent:8", "change_percent", 8, false, true},
+		{"change_percent:9", "", 0, false, false},
+		{"change:-1", "", 0, false, false},
+		{"price:long", "", 0, false, false},
+		{"ticker:short", "", 0, false, false},
+		{"currency:2", "", 0, false, false},
+		{"market_cap", "", 0, false, false},
+		{"", "", 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			column, err := parseColumn(tt.spec)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("parseColumn succeeded with %s, want an error", column.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseColumn: %v", err)
+			}
+			if column.Name() != tt.name || column.decimals != tt.decimals || column.short != tt.short {
+				t.Errorf("column = %s, decimals %d, short %t, want %s, %d, %t", column.Name(), column.decimals, column.short, tt.name, tt.decimals, tt.short)
+			}
+		})
+	}
+}
+
+func TestParseColumnsDefault(t *testing.T) {
+	columns, err := ParseColumns(nil)
+	if err != nil {
+		t.Fatalf("ParseColumns: %v", err)
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name()
+	}
+	if !reflect.DeepEqual(names, DefaultColumns) {
+		t.Errorf("columns = %q, want %q", names, DefaultColumns)
+	}
+}
+
+func TestAbbreviate(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		want     string
+	}{
+		{0, 1, "0.0"},
+		{999, 0, "999"},
+		{1000, 1, "1.0K"},
+		{1234, 2, "1.23K"},
+		{999960, 1, "1.0M"}, // rounding carries over into the next suffix
+		{999940, 1, "999.9K"},
+		{70122748, 1, "70.1M"},
+		{2.5e9, 1, "2.5B"},
+		{3.1e12, 2, "3.10T"},
+		{4.2e15, 0, "4200T"},
+		{-1234567, 1, "-1.2M"},
+		{-0.004, 2, "0.00"},
+		{-0.4, 0, "0"},
+	}
+
+	for _, tt := range tests {
+		if got := abbreviate(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("abbreviate(%g, %d) = %s, want %s", tt.value, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestColumnCell(t *testing.T) {
+	stock := model.StockData{
+		Ticker:        "AAPL",
+		LastPrice:     212.4912,
+		Change:        -0.004,
+		ChangePercent: -0.3,
+		Volume:        70122748,
+		DayLow:        211.3,
+		DayHigh:       215.17,
+		Currency:      "USD",
+	}
+
+	tests := []struct {
+		spec  string
+		stock model.StockData
+		want  string
+	}{
+		{"ticker", stock, "AAPL"},
+		{"price", stock, "212.49"},
+		{"price:4", stock, "212.4912"},
+		{"price:short:0", stock, "212"},
+		{"open", stock, "-"},
+		{"day_range", stock, "211.30 - 215.17"},
+		{"day_range:0", stock, "211 - 215"},
+		{"52w_range", stock, "-"},
+		{"volume", stock, "70,122,748"},
+		{"volume:2", stock, "70,122,748.00"},
+		{"volume:short", stock, "70.1M"},
+		{"volume:short:2", stock, "70.12M"},
+		{"avg_volume", stock, "-"},
+		{"change", stock, "+0.00"},
+		{"change:4", stock, "-0.0040"},
+		{"change_percent", stock, "-0.30"},
+		// Small negative changes round to an unsigned zero rather than "-0"
+		{"change_percent:0", stock, "+0"},
+		{"change_percent:short:0", stock, "+0"},
+		{"change_percent", model.StockData{ChangePercent: 1.234}, "+1.23"},
+		{"change_percent", model.StockData{ChangePercent: math.NaN()}, "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			column, err := parseColumn(tt.spec)
+			if err != nil {
+				t.Fatalf("parseColumn: %v", err)
+			}
+			if got := column.cell(tt.stock); got != tt.want {
+				t.Errorf("cell = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColoredChangeCell(t *testing.T) {
+	tests := []struct {
+		cell  string
+		color text.Color
+	}{
+		{"+1.23", text.FgGreen},
+		{"+0", text.FgGreen},
+		{"-0.82", text.FgRed},
+	}
+
+	for _, tt := range tests {
+		if got, want := getColoredChangeCell(tt.cell, "%"), (text.Colors{tt.color}).Sprint(tt.cell+"%"); got != want {
+			t.Errorf("getColoredChangeCell(%q) = %q, want %q", tt.cell, got, want)
+		}
+	}
+}
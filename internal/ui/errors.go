@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"context"
+	"errors"
+
+	"stockterm/internal/api"
+)
+
+// errorKinds are the kinds of fetch errors, in the order they are matched,
+// with the stable identifier written in JSON output and the label shown in tables
+var errorKinds = []struct {
+	err   error
+	id    string
+	label string
+}{
+	{api.ErrNotFound, "not_found", "not found"},
+	{api.ErrRateLimited, "rate_limited", "rate limited"},
+	{api.ErrNetwork, "network", "network error"},
+	{api.ErrDecode, "invalid_response", "invalid response"},
+	{api.ErrServer, "server", "server error"},
+	{api.ErrUnsupported, "unsupported", "unsupported range or interval"},
+	{api.ErrUnauthorized, "unauthorized", "unauthorized"},
+	{context.DeadlineExceeded, "timeout", "timed out"},
+	{context.Canceled, "cancelled", "cancelled"},
+}
+
+// errorKind returns the identifier and label of a fetch error's kind. Errors
+// of no known kind are identified as "other" and labelled with their message.
+func errorKind(err error) (id, label string) {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.err) {
+			return kind.id, kind.label
+		}
+	}
+	return "other", err.Error()
+}
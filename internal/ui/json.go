@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"encoding/json"
+	"time"
+
+	"stockterm/internal/api"
+)
+
+// JSONVersion is the version of the JSON output schema. It changes only when
+// fields are removed or change meaning; fields may be added within a version.
+const JSONVersion = 1
+
+// jsonOutput is the document written by RenderJSON
+type jsonOutput struct {
+	Version  int         `json:"version"`
+	Range    string      `json:"range"`
+	Interval string      `json:"interval,omitempty"`
+	Stocks   []jsonStock `json:"stocks"`
+}
+
+// jsonStock is a ticker in the JSON output. Ticker is always set; Error is set
+// if the ticker could not be fetched, and the other fields otherwise. Values
+// that are unknown are left out.
+type jsonStock struct {
+	Ticker           string     `json:"ticker"`
+	Error            *jsonError `json:"error,omitempty"`
+	Currency         string     `json:"currency,omitempty"`
+	Price            *float64   `json:"price,omitempty"`
+	Change           *float64   `json:"change,omitempty"`
+	ChangePercent    *float64   `json:"change_percent,omitempty"`
+	PreviousClose    *float64   `json:"previous_close,omitempty"`
+	Open             *float64   `json:"open,omitempty"`
+	DayHigh          *float64   `json:"day_high,omitempty"`
+	DayLow           *float64   `json:"day_low,omitempty"`
+	Volume           *int64     `json:"volume,omitempty"`
+	FiftyTwoWeekHigh *float64   `json:"fifty_two_week_high,omitempty"`
+	FiftyTwoWeekLow  *float64   `json:"fifty_two_week_low,omitempty"`
+	AverageVolume    *int64     `json:"average_volume,omitempty"`
+	StaleAsOf        *time.Time `json:"stale_as_of,omitempty"`
+}
+
+// jsonError describes why a ticker could not be fetched
+type jsonError struct {
+	// Kind is a stable identifier of the kind of error
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// RenderJSON writes fetch results as a versioned JSON document, sorted and
+// limited to the top movers like RenderResults. Columns and styles don't
+// apply; every known value is written.
+func (r *TableRenderer) RenderJSON(results []api.Result, timeRange, interval string) error {
+	output := jsonOutput{
+		Version:  JSONVersion,
+		Range:    timeRange,
+		Interval: interval,
+		Stocks:   []jsonStock{},
+	}
+
+	for _, row := range r.resultRows(results) {
+		if row.Err != nil {
+			kind, _ := errorKind(row.Err)
+			output.Stocks = append(output.Stocks, jsonStock{
+				Ticker: row.Ticker,
+				Error:  &jsonError{Kind: kind, Message: row.Err.Error()},
+			})
+			continue
+		}
+
+		stock := row.stock
+		entry := jsonStock{
+			Ticker:           stock.Ticker,
+			Currency:         stock.Currency,
+			Price:            knownPrice(stock.LastPrice),
+			Change:           finite(stock.Change),
+			ChangePercent:    finite(stock.ChangePercent),
+			PreviousClose:    knownPrice(stock.PreviousClose),
+			Open:             knownPrice(stock.Open),
+			DayHigh:          knownPrice(stock.DayHigh),
+			DayLow:           knownPrice(stock.DayLow),
+			Volume:           knownVolume(stock.Volume),
+			FiftyTwoWeekHigh: knownPrice(stock.FiftyTwoWeekHigh),
+			FiftyTwoWeekLow:  knownPrice(stock.FiftyTwoWeekLow),
+			AverageVolume:    knownVolume(stock.AverageVolume),
+		}
+		if entry.Ticker == "" {
+			entry.Ticker = row.Ticker
+		}
+		if !row.StaleAsOf.IsZero() {
+			staleAsOf := row.StaleAsOf.UTC()
+			entry.StaleAsOf = &staleAsOf
+		}
+		output.Stocks = append(output.Stocks, entry)
+	}
+
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// finite returns a pointer to value, or nil if it is NaN or infinite
+func finite(value float64) *float64 {
+	if !isFinite(value) {
+		return nil
+	}
+	return &value
+}
+
+// knownPrice returns a pointer to price, or nil if it is unknown
+func knownPrice(price float64) *float64 {
+	if price == 0 {
+		return nil
+	}
+	return finite(price)
+}
+
+// knownVolume returns a pointer to volume, or nil if it is unknown
+func knownVolume(volume int64) *int64 {
+	if volume == 0 {
+		return nil
+	}
+	return &volume
+}
@@ -11,6 +11,7 @@ type WatchlistModel struct {
 	choices  []string         // items in the watchlist
 	cursor   int              // which item the cursor is pointing at
 	selected map[int]struct{} // which items are selected
+	grabbed  bool             // whether the item at the cursor moves along with it
 	done     bool             // whether the user is done editing
 	saved    bool             // whether the changes have been saved
 }
@@ -45,18 +46,40 @@ func (m WatchlistModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "up", "k":
-			// Move cursor up
-			if m.cursor > 0 {
+			// Move cursor up, along with the grabbed item
+			if m.grabbed {
+				m.move(-1)
+			} else if m.cursor > 0 {
 				m.cursor--
 			}
 
 		case "down", "j":
-			// Move cursor down
-			if m.cursor < len(m.choices)-1 {
+			// Move cursor down, along with the grabbed item
+			if m.grabbed {
+				m.move(1)
+			} else if m.cursor < len(m.choices)-1 {
 				m.cursor++
 			}
 
+		case "shift+up", "K":
+			// Move the item up
+			m.move(-1)
+
+		case "shift+down", "J":
+			// Move the item down
+			m.move(1)
+
+		case "m":
+			// Grab or drop the item
+			m.grabbed = !m.grabbed
+
 		case "enter", " ":
+			// Drop the grabbed item
+			if m.grabbed {
+				m.grabbed = false
+				break
+			}
+
 			// Toggle selection
 			_, ok := m.selected[m.cursor]
 			if ok {
@@ -70,6 +93,30 @@ func (m WatchlistModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// move swaps the item at the cursor with the one offset positions away, moving the cursor along with it
+func (m *WatchlistModel) move(offset int) {
+	target := m.cursor + offset
+	if target < 0 || target >= len(m.choices) {
+		return
+	}
+
+	m.choices[m.cursor], m.choices[target] = m.choices[target], m.choices[m.cursor]
+
+	// Keep the selection with the items
+	_, cursorSelected := m.selected[m.cursor]
+	_, targetSelected := m.selected[target]
+	delete(m.selected, m.cursor)
+	delete(m.selected, target)
+	if cursorSelected {
+		m.selected[target] = struct{}{}
+	}
+	if targetSelected {
+		m.selected[m.cursor] = struct{}{}
+	}
+
+	m.cursor = target
+}
+
 // View renders the model
 func (m WatchlistModel) View() string {
 	// The header
@@ -81,6 +128,9 @@ func (m WatchlistModel) View() string {
 		cursor := " " // no cursor
 		if m.cursor == i {
 			cursor = ">" // cursor!
+			if m.grabbed {
+				cursor = "=" // moving along with the cursor
+			}
 		}
 
 		// Is this choice selected?
@@ -94,6 +144,8 @@ func (m WatchlistModel) View() string {
 	}
 
 	// The footer
+	s += "\nPress space to remove or keep a ticker."
+	s += "\nPress shift+up/down or K/J to move a ticker, or m to grab it and move it with the cursor."
 	s += "\nPress s to save.\nPress q to quit without saving.\n"
 
 	return s
@@ -122,24 +174,23 @@ func (m WatchlistModel) IsDone() bool {
 	return m.done
 }
 
-// RunWatchlistEditor runs the watchlist editor and returns the updated watchlist
-func RunWatchlistEditor(watchlist []string) ([]string, bool) {
+// RunWatchlistEditor runs the watchlist editor and returns the updated
+// watchlist, and whether the user saved it
+func RunWatchlistEditor(watchlist []string) ([]string, bool, error) {
 	p := tea.NewProgram(NewWatchlistModel(watchlist))
 	model, err := p.Run()
 	if err != nil {
-		fmt.Printf("Error running watchlist editor: %v\n", err)
-		return watchlist, false
+		return watchlist, false, fmt.Errorf("error running watchlist editor: %w", err)
 	}
 
 	watchlistModel, ok := model.(WatchlistModel)
 	if !ok {
-		fmt.Println("Error: could not convert model to WatchlistModel")
-		return watchlist, false
+		return watchlist, false, fmt.Errorf("watchlist editor returned an unexpected model %T", model)
 	}
 
 	if !watchlistModel.IsSaved() {
-		return watchlist, false
+		return watchlist, false, nil
 	}
 
-	return watchlistModel.GetRemainingChoices(), true
+	return watchlistModel.GetRemainingChoices(), true, nil
 }
@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"stockterm/internal/api"
+	"stockterm/internal/model"
+)
+
+// Sort describes how the rows of stock tables are sorted. The zero value
+// keeps rows in the order they were fetched in.
+type Sort struct {
+	def        *columnDef
+	descending bool
+}
+
+// ParseSort parses a sort spec: a column name optionally followed by ":asc"
+// or ":desc", as in "change_percent:desc". Rows are sorted in ascending order
+// by default.
+func ParseSort(spec string) (Sort, error) {
+	name, direction, _ := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+
+	def, err := findColumn(name)
+	if err != nil {
+		return Sort{}, err
+	}
+
+	switch direction {
+	case "", "asc":
+		return Sort{def: def}, nil
+	case "desc":
+		return Sort{def: def, descending: true}, nil
+	default:
+		return Sort{}, fmt.Errorf("invalid sort direction '%s': expected 'asc' or 'desc'", direction)
+	}
+}
+
+// resultRow is a fetch result along with the stock data shown for it
+type resultRow struct {
+	api.Result
+	stock model.StockData
+}
+
+// resultRows returns the rows shown for fetch results: their stock data,
+// limited to the top movers and sorted if the renderer is set to
+func (r *TableRenderer) resultRows(results []api.Result) []resultRow {
+	rows := make([]resultRow, 0, len(results))
+	for _, result := range results {
+		row := resultRow{Result: result}
+		if result.Err == nil {
+			row.stock = model.NewStockData(result.Response)
+			row.stock.AverageVolume = r.averageVolumes[result.Ticker]
+		}
+		rows = append(rows, row)
+	}
+
+	if r.top > 0 {
+		rows = topMovers(rows, r.top)
+	}
+
+	if r.sort.def != nil {
+		sort.SliceStable(rows, func(i, j int) bool {
+			return r.sort.less(rows[i], rows[j])
+		})
+	}
+
+	return rows
+}
+
+// topMovers returns the n rows with the largest absolute change in percent,
+// largest first. Rows that failed or whose change is unknown are left out.
+func topMovers(rows []resultRow, n int) []resultRow {
+	var movers []resultRow
+	for _, row := range rows {
+		if row.Err == nil && isFinite(row.stock.ChangePercent) {
+			movers = append(movers, row)
+		}
+	}
+
+	sort.SliceStable(movers, func(i, j int) bool {
+		return math.Abs(movers[i].stock.ChangePercent) > math.Abs(movers[j].stock.ChangePercent)
+	})
+
+	if len(movers) > n {
+		movers = movers[:n]
+	}
+	return movers
+}
+
+// less reports whether row a is sorted before row b. Rows that failed, and
+// rows whose value is unknown, are kept last whatever the direction.
+func (s Sort) less(a, b resultRow) bool {
+	aKnown, bKnown := s.known(a), s.known(b)
+	if !aKnown || !bKnown {
+		return aKnown && !bKnown
+	}
+
+	if s.def.kind == kindText {
+		ta, tb := s.def.text(a.stock), s.def.text(b.stock)
+		if s.descending {
+			return ta > tb
+		}
+		return ta < tb
+	}
+
+	va, vb := s.def.value(a.stock), s.def.value(b.stock)
+	if s.descending {
+		return va > vb
+	}
+	return va < vb
+}
+
+// known reports whether a row has a value to sort by
+func (s Sort) known(row resultRow) bool {
+	if row.Err != nil {
+		return false
+	}
+
+	switch s.def.kind {
+	case kindText:
+		return s.def.text(row.stock) != ""
+	case kindChange, kindPercent:
+		return isFinite(s.def.value(row.stock))
+	default:
+		// Prices and volumes of zero are unknown
+		value := s.def.value(row.stock)
+		return isFinite(value) && value != 0
+	}
+}
+
+// isFinite reports whether a number is neither NaN nor infinite
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
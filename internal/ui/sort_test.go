@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"stockterm/internal/api"
+	"stockterm/internal/model"
+)
+
+// testRows returns rows for stocks with the given prices and changes in
+// percent, plus a failed row
+func testRows() []resultRow {
+	stocks := []model.StockData{
+		{Ticker: "AAPL", LastPrice: 212.49, ChangePercent: -0.82, Volume: 70122748},
+		{Ticker: "MSFT", LastPrice: 442.57, ChangePercent: 0.22, Volume: 13585619},
+		{Ticker: "NVDA", LastPrice: 120.1, ChangePercent: 3.5},
+		{Ticker: "TSLA", LastPrice: 0, ChangePercent: math.NaN(), Volume: 90000000},
+		{Ticker: "AMD", LastPrice: 160.2, ChangePercent: -4.1, Volume: 50000000},
+	}
+
+	rows := make([]resultRow, 0, len(stocks)+1)
+	for _, stock := range stocks {
+		rows = append(rows, resultRow{Result: api.Result{Ticker: stock.Ticker}, stock: stock})
+	}
+	return append(rows, resultRow{Result: api.Result{Ticker: "BAD", Err: errors.New("not found")}})
+}
+
+// tickers returns the tickers of rows, in order
+func tickers(rows []resultRow) []string {
+	names := make([]string, len(rows))
+	for i, row := range rows {
+		names[i] = row.Ticker
+	}
+	return names
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec       string
+		name       string
+		descending bool
+		valid      bool
+	}{
+		{"price", "price", false, true},
+		{"price:asc", "price", false, true},
+		{" Change_Percent:DESC ", "change_percent", true, true},
+		{"ticker:desc", "ticker", true, true},
+		{"price:down", "", false, false},
+		{"market_cap:desc", "", false, false},
+		{"", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSort(tt.spec)
+			if !tt.valid {
+				if err == nil {
+					t.Error("ParseSort succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSort: %v", err)
+			}
+			if s.def.name != tt.name || s.descending != tt.descending {
+				t.Errorf("sort = %s, descending %t, want %s, %t", s.def.name, s.descending, tt.name, tt.descending)
+			}
+		})
+	}
+}
+
+func TestSortLess(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		// Rows whose value is unknown, and failed rows, stay last in either direction
+		{"price", []string{"NVDA", "AMD", "AAPL", "MSFT", "TSLA", "BAD"}},
+		{"price:desc", []string{"MSFT", "AAPL", "AMD", "NVDA", "TSLA", "BAD"}},
+		{"change_percent", []string{"AMD", "AAPL", "MSFT", "NVDA", "TSLA", "BAD"}},
+		{"change_percent:desc", []string{"NVDA", "MSFT", "AAPL", "AMD", "TSLA", "BAD"}},
+		{"volume:desc", []string{"TSLA", "AAPL", "AMD", "MSFT", "NVDA", "BAD"}},
+		{"ticker", []string{"AAPL", "AMD", "MSFT", "NVDA", "TSLA", "BAD"}},
+		{"ticker:desc", []string{"TSLA", "NVDA", "MSFT", "AMD", "AAPL", "BAD"}},
+		// Rows without a currency keep their order
+		{"currency", []string{"AAPL", "MSFT", "NVDA", "TSLA", "AMD", "BAD"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSort(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSort: %v", err)
+			}
+
+			rows := testRows()
+			sort.SliceStable(rows, func(i, j int) bool { return s.less(rows[i], rows[j]) })
+			if got := tickers(rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopMovers(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{1, []string{"AMD"}},
+		{3, []string{"AMD", "NVDA", "AAPL"}},
+		// Failed rows and rows whose change is unknown are left out
+		{10, []string{"AMD", "NVDA", "AAPL", "MSFT"}},
+	}
+
+	for _, tt := range tests {
+		if got := tickers(topMovers(testRows(), tt.n)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("topMovers(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestResultRowsTopThenSort(t *testing.T) {
+	results := fetchFixtures(t)
+
+	tests := []struct {
+		sort string
+		top  int
+		want []string
+	}{
+		{"", 0, []string{"AAPL", "MSFT", "INVALIDTICKER"}},
+		{"ticker:desc", 0, []string{"MSFT", "AAPL", "INVALIDTICKER"}},
+		{"", 1, []string{"AAPL"}},
+		// The top movers are picked first, then sorted
+		{"change_percent:desc", 2, []string{"MSFT", "AAPL"}},
+	}
+
+	for _, tt := range tests {
+		renderer := NewTableRenderer().WithTop(tt.top)
+		if tt.sort != "" {
+			s, err := ParseSort(tt.sort)
+			if err != nil {
+				t.Fatalf("ParseSort: %v", err)
+			}
+			renderer.WithSort(s)
+		}
+
+		if got := tickers(renderer.resultRows(results)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sort %q, top %d: rows = %q, want %q", tt.sort, tt.top, got, tt.want)
+		}
+	}
+}
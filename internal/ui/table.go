@@ -1,28 +1,39 @@
 package ui
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 
+	"stockterm/internal/api"
 	"stockterm/internal/model"
 )
 
 // TableRenderer renders stock data in a table
 type TableRenderer struct {
-	writer io.Writer
-	style  table.Style
+	writer  io.Writer
+	style   table.Style
+	title   string
+	columns []Column
+	sort    Sort
+	top     int
+	// averageVolumes maps tickers to their average daily volume
+	averageVolumes map[string]int64
 }
 
 // NewTableRenderer creates a new table renderer
 func NewTableRenderer() *TableRenderer {
+	// The default columns are always valid
+	columns, _ := ParseColumns(nil)
+
 	return &TableRenderer{
-		writer: os.Stdout,
-		style:  table.StyleColoredCyanWhiteOnBlack,
+		writer:  os.Stdout,
+		style:   table.StyleColoredCyanWhiteOnBlack,
+		columns: columns,
 	}
 }
 
@@ -38,42 +49,222 @@ func (r *TableRenderer) WithStyle(style table.Style) *TableRenderer {
 	return r
 }
 
+// WithTitle sets the title shown above the table
+func (r *TableRenderer) WithTitle(title string) *TableRenderer {
+	r.title = title
+	return r
+}
+
+// WithColumns sets the columns of stock tables, in order
+func (r *TableRenderer) WithColumns(columns []Column) *TableRenderer {
+	r.columns = columns
+	return r
+}
+
+// WithAverageVolumes sets the average daily volume of each ticker, which is
+// fetched separately from its chart. Tickers without one show it as unknown.
+func (r *TableRenderer) WithAverageVolumes(volumes map[string]int64) *TableRenderer {
+	r.averageVolumes = volumes
+	return r
+}
+
+// Uses reports whether stock tables show or are sorted by the named column
+func (r *TableRenderer) Uses(name string) bool {
+	return r.hasColumn(name) || r.sort.def != nil && r.sort.def.name == name
+}
+
+// WithSort sets how the rows of stock tables are sorted
+func (r *TableRenderer) WithSort(sort Sort) *TableRenderer {
+	r.sort = sort
+	return r
+}
+
+// WithTop limits stock tables to the n tickers with the largest absolute
+// change in percent, largest first unless they are sorted otherwise. Zero
+// shows every ticker.
+func (r *TableRenderer) WithTop(n int) *TableRenderer {
+	r.top = n
+	return r
+}
+
 // RenderStocks renders a table of stock data
 func (r *TableRenderer) RenderStocks(stocks []model.StockData) {
+	t := r.newStockTable(r.stockHeader())
+	for _, stock := range stocks {
+		t.AppendRow(r.stockRow(stock))
+	}
+	t.Render()
+}
+
+// RenderResults renders a table of fetch results, showing failed tickers as
+// error rows. If any result holds stale data, a status column marks those rows.
+// Rows are sorted and limited to the top movers as set with WithSort and WithTop.
+func (r *TableRenderer) RenderResults(results []api.Result) {
+	rows := r.resultRows(results)
+
+	header := r.stockHeader()
+	withStatus := hasStaleResults(rows)
+	if withStatus {
+		header = append(header, "Status")
+	}
+
+	t := r.newStockTable(header)
+	for _, result := range rows {
+		if result.Err != nil {
+			t.AppendRow(r.errorRow(result.Ticker, result.Err, len(header)))
+			continue
+		}
+
+		row := r.stockRow(result.stock)
+		if withStatus {
+			row = append(row, staleCell(result.StaleAsOf))
+		}
+		t.AppendRow(row)
+	}
+	t.Render()
+}
+
+// newStockTable creates a table writer with the stock columns configured
+func (r *TableRenderer) newStockTable(header table.Row) table.Writer {
 	t := table.NewWriter()
 	t.SetOutputMirror(r.writer)
-	t.AppendHeader(table.Row{"Ticker", "Last Price", "Change", "Change %", "Prev. Close", "Currency"})
+	t.SetTitle(r.title)
+	t.AppendHeader(header)
 
-	for _, stock := range stocks {
-		t.AppendRow(table.Row{
-			stock.Ticker,
-			fmt.Sprintf("%.2f", stock.LastPrice),
-			appendPlus(stock.Change),
-			appendPlus(stock.ChangePercent),
-			fmt.Sprintf("%.2f", stock.PreviousClose),
-			stock.Currency,
-		})
-	}
+	// Color changes by their sign
+	var configs []table.ColumnConfig
+	for i, column := range r.columns {
+		if column.def.kind != kindChange && column.def.kind != kindPercent {
+			continue
+		}
+		postfix := ""
+		if column.def.kind == kindPercent {
+			postfix = "%"
+		}
 
-	t.SetColumnConfigs([]table.ColumnConfig{
-		{
-			Name: "Change",
+		configs = append(configs, table.ColumnConfig{
+			Number: i + 1,
 			Transformer: text.Transformer(func(val interface{}) string {
-				return getColoredChangeCell(val, "")
+				return getColoredChangeCell(val, postfix)
 			}),
-		},
-		{
-			Name: "Change %",
-			Transformer: text.Transformer(func(val interface{}) string {
-				return getColoredChangeCell(val, "%")
-			}),
-		},
-	})
+		})
+	}
+	t.SetColumnConfigs(configs)
 
+	t.SetStyle(r.style)
+	return t
+}
+
+// stockHeader returns the header of the stock table
+func (r *TableRenderer) stockHeader() table.Row {
+	header := make(table.Row, 0, len(r.columns))
+	for _, column := range r.columns {
+		header = append(header, column.def.header)
+	}
+	return header
+}
+
+// stockRow returns the table row for a stock
+func (r *TableRenderer) stockRow(stock model.StockData) table.Row {
+	row := make(table.Row, 0, len(r.columns))
+	for _, column := range r.columns {
+		row = append(row, column.cell(stock))
+	}
+	return row
+}
+
+// errorRow returns the row for a ticker that could not be fetched. The error
+// message is shown in the first column other than the ticker column, and
+// names the ticker if there is no ticker column.
+func (r *TableRenderer) errorRow(ticker string, err error, columns int) table.Row {
+	_, label := errorKind(err)
+	message := "error: " + label
+	if !r.hasColumn("ticker") {
+		message = ticker + ": " + message
+	}
+
+	row := make(table.Row, 0, columns)
+	shown := false
+	for _, column := range r.columns {
+		switch {
+		case column.def.name == "ticker":
+			row = append(row, ticker)
+		case !shown:
+			row = append(row, errorCell(text.Colors{text.FgRed}.Sprint(message)))
+			shown = true
+		default:
+			row = append(row, errorCell(""))
+		}
+	}
+	if !shown {
+		row = append(row, errorCell(text.Colors{text.FgRed}.Sprint(message)))
+	}
+	for len(row) < columns {
+		row = append(row, errorCell(""))
+	}
+	return row
+}
+
+// hasColumn reports whether the stock table shows the named column
+func (r *TableRenderer) hasColumn(name string) bool {
+	for _, column := range r.columns {
+		if column.def.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasStaleResults reports whether any successful result holds stale data
+func hasStaleResults(rows []resultRow) bool {
+	for _, result := range rows {
+		if result.Err == nil && !result.StaleAsOf.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// staleCell returns the status cell for data fetched at asOf, which is empty for fresh data
+func staleCell(asOf time.Time) string {
+	if asOf.IsZero() {
+		return ""
+	}
+	return text.Colors{text.FgYellow}.Sprint("stale as of " + asOf.Local().Format("2006-01-02 15:04"))
+}
+
+// errorCell is a cell holding an error message, left untouched by column transformers
+type errorCell string
+
+// RenderSearchResults renders a table of search results, numbered from 1
+func (r *TableRenderer) RenderSearchResults(results []model.SearchResult) {
+	t := table.NewWriter()
+	t.SetOutputMirror(r.writer)
+	t.SetTitle(r.title)
+	t.AppendHeader(table.Row{"#", "Symbol", "Name", "Exchange", "Type"})
+	for i, result := range results {
+		t.AppendRow(table.Row{
+			i + 1,
+			result.Symbol,
+			firstNonEmpty(result.LongName, result.ShortName),
+			firstNonEmpty(result.ExchangeDisplay, result.Exchange),
+			firstNonEmpty(result.TypeDisplay, result.QuoteType),
+		})
+	}
 	t.SetStyle(r.style)
 	t.Render()
 }
 
+// firstNonEmpty returns the first of values that isn't empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 // RenderChartResponses renders a table of chart responses
 func (r *TableRenderer) RenderChartResponses(responses []model.ChartResponse) {
 	var stocks []model.StockData
@@ -85,10 +276,18 @@ func (r *TableRenderer) RenderChartResponses(responses []model.ChartResponse) {
 
 // getColoredChangeCell returns a colored cell for a change value
 func getColoredChangeCell(val interface{}, postfix string) string {
+	if msg, ok := val.(errorCell); ok {
+		return string(msg)
+	}
+
 	strVal, ok := val.(string)
 	if !ok {
 		return "0.00" + postfix
 	}
+	if strVal == "-" {
+		// The change is unknown
+		return strVal
+	}
 
 	var color text.Color
 	if strings.Contains(strVal, "-") {
@@ -99,11 +298,3 @@ func getColoredChangeCell(val interface{}, postfix string) string {
 
 	return text.Colors{color}.Sprint(strVal + postfix)
 }
-
-// appendPlus adds a plus sign to positive numbers
-func appendPlus(num float64) string {
-	if num >= 0 {
-		return fmt.Sprintf("+%.2f", num)
-	}
-	return fmt.Sprintf("%.2f", num)
-}
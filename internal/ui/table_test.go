@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"stockterm/internal/api"
+)
+
+// fetchFixtures fetches AAPL, MSFT and INVALIDTICKER from the recorded fixtures
+func fetchFixtures(t *testing.T) []api.Result {
+	t.Helper()
+
+	client := api.NewYahooFinanceClient(
+		api.WithTransport(api.NewFixtureTransport("../api/testdata", api.FixtureReplay)),
+		api.WithRetryPolicy(api.RetryPolicy{MaxAttempts: 1}),
+	)
+	results, err := client.FetchMultipleStocks(context.Background(), []string{"AAPL", "MSFT", "INVALIDTICKER"}, "1d", "")
+	if err != nil {
+		t.Fatalf("FetchMultipleStocks: %v", err)
+	}
+	return results
+}
+
+func TestRenderResults(t *testing.T) {
+	var buf bytes.Buffer
+	NewTableRenderer().WithWriter(&buf).WithStyle(table.StyleDefault).RenderResults(fetchFixtures(t))
+
+	lines := strings.Split(buf.String(), "\n")
+	for _, want := range []struct {
+		ticker string
+		values []string
+	}{
+		{"AAPL", []string{"212.49", "-1.75", "-0.82%", "211.30 - 215.17", "164.08 - 220.20", "70,122,748", "214.24", "USD"}},
+		{"MSFT", []string{"442.57", "+0.99", "+0.22%", "436.72 - 443.34", "309.45 - 443.34", "13,585,619", "441.58", "USD"}},
+		{"INVALIDTICKER", []string{"not found"}},
+	} {
+		line := findLine(lines, want.ticker)
+		if line == "" {
+			t.Errorf("no row for %s in:\n%s", want.ticker, buf.String())
+			continue
+		}
+		for _, value := range want.values {
+			if !strings.Contains(line, value) {
+				t.Errorf("row for %s lacks %q: %s", want.ticker, value, line)
+			}
+		}
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	var buf bytes.Buffer
+	renderer := NewTableRenderer().WithWriter(&buf).WithAverageVolumes(map[string]int64{"AAPL": 60188574})
+	if err := renderer.RenderJSON(fetchFixtures(t), "1d", ""); err != nil {
+		t.Fatalf("RenderJSON: %v", err)
+	}
+
+	var output jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &output); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if output.Version != JSONVersion || output.Range != "1d" || len(output.Stocks) != 3 {
+		t.Fatalf("unexpected document: %s", buf.String())
+	}
+
+	aapl := output.Stocks[0]
+	if aapl.Ticker != "AAPL" || aapl.Price == nil || *aapl.Price != 212.49 || aapl.Volume == nil || *aapl.Volume != 70122748 {
+		t.Errorf("unexpected AAPL entry: %+v", aapl)
+	}
+	if aapl.AverageVolume == nil || *aapl.AverageVolume != 60188574 {
+		t.Errorf("AAPL average volume = %v, want 60188574", aapl.AverageVolume)
+	}
+	if msft := output.Stocks[1]; msft.AverageVolume != nil {
+		t.Errorf("MSFT average volume = %d, want it left out", *msft.AverageVolume)
+	}
+	if invalid := output.Stocks[2]; invalid.Error == nil || invalid.Error.Kind != "not_found" || invalid.Price != nil {
+		t.Errorf("unexpected INVALIDTICKER entry: %+v", invalid)
+	}
+}
+
+// findLine returns the first line holding the ticker as a whole cell
+func findLine(lines []string, ticker string) string {
+	for _, line := range lines {
+		if strings.Contains(line, " "+ticker+" ") {
+			return line
+		}
+	}
+	return ""
+}
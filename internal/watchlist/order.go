@@ -0,0 +1,45 @@
+package watchlist
+
+import (
+	"math"
+	"sort"
+
+	"stockterm/internal/api"
+	"stockterm/internal/config"
+	"stockterm/internal/model"
+)
+
+// OrderResults sorts fetched results into a watchlist order that depends on
+// quotes. Results that failed, or whose change is unknown, are kept last in
+// their original order. Other orders leave the results untouched, since
+// GetWatchlist already returns tickers in those orders.
+func OrderResults(order config.WatchlistOrder, results []api.Result) {
+	var key func(result api.Result) (float64, bool)
+
+	switch order {
+	case config.OrderChangePercent:
+		key = func(result api.Result) (float64, bool) {
+			changePercent := model.NewStockData(result.Response).ChangePercent
+			return changePercent, !math.IsNaN(changePercent) && !math.IsInf(changePercent, 0)
+		}
+	default:
+		return
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		ki, iok := resultKey(results[i], key)
+		kj, jok := resultKey(results[j], key)
+		if iok != jok {
+			return iok
+		}
+		return iok && ki > kj
+	})
+}
+
+// resultKey returns the sort key of a result, and false if it has none
+func resultKey(result api.Result, key func(result api.Result) (float64, bool)) (float64, bool) {
+	if result.Err != nil {
+		return 0, false
+	}
+	return key(result)
+}
@@ -0,0 +1,209 @@
+package watchlist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"stockterm/internal/config"
+)
+
+// TagFilter selects tickers by their tags
+type TagFilter struct {
+	// Include are the tags a ticker must all have
+	Include []string
+	// Exclude are the tags a ticker must not have
+	Exclude []string
+}
+
+// ParseTagFilter parses tag filter terms. Terms are tags that tickers must
+// have, or tags prefixed with "-" that tickers must not have.
+func ParseTagFilter(terms []string) (TagFilter, error) {
+	var filter TagFilter
+
+	for _, term := range terms {
+		exclude := strings.HasPrefix(term, "-")
+		tag, err := NormalizeTag(strings.TrimPrefix(term, "-"))
+		if err != nil {
+			return TagFilter{}, err
+		}
+
+		if exclude {
+			filter.Exclude = append(filter.Exclude, tag)
+		} else {
+			filter.Include = append(filter.Include, tag)
+		}
+	}
+
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter matches every ticker
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Matches reports whether a ticker with the given tags passes the filter
+func (f TagFilter) Matches(tags []string) bool {
+	for _, tag := range f.Include {
+		if !hasTag(tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range f.Exclude {
+		if hasTag(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTag returns tag in lower case, or an error if it is not a valid tag.
+// Tags can't be empty, contain whitespace or commas, or start with "-", which
+// marks excluded tags in filters.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	switch {
+	case tag == "":
+		return "", fmt.Errorf("tag cannot be empty")
+	case strings.HasPrefix(tag, "-"):
+		return "", fmt.Errorf("invalid tag %q: tags cannot start with '-'", tag)
+	case strings.ContainsAny(tag, ", \t"):
+		return "", fmt.Errorf("invalid tag %q: tags cannot contain commas or whitespace", tag)
+	}
+
+	return tag, nil
+}
+
+// GetTaggedWatchlist returns the tickers in the current watchlist that pass
+// the filter, in the same order as GetWatchlist
+func (s *Service) GetTaggedWatchlist(filter TagFilter) ([]string, error) {
+	watchlist, err := s.loadOrdered()
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := []string{}
+	for _, entry := range watchlist.Entries {
+		if filter.Matches(entry.Tags) {
+			tagged = append(tagged, entry.Symbol)
+		}
+	}
+
+	if watchlist.Order == config.OrderAlphabetical {
+		sort.Strings(tagged)
+	}
+
+	return tagged, nil
+}
+
+// AddTags adds tags to a ticker in the watchlist. Tags the ticker already has are ignored.
+func (s *Service) AddTags(ticker string, tags []string) error {
+	return s.modifyEntry(ticker, func(entry *config.WatchlistEntry) error {
+		for _, tag := range tags {
+			tag, err := NormalizeTag(tag)
+			if err != nil {
+				return err
+			}
+			if !hasTag(entry.Tags, tag) {
+				entry.Tags = append(entry.Tags, tag)
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveTags removes tags from a ticker in the watchlist
+func (s *Service) RemoveTags(ticker string, tags []string) error {
+	return s.modifyEntry(ticker, func(entry *config.WatchlistEntry) error {
+		for _, tag := range tags {
+			tag, err := NormalizeTag(tag)
+			if err != nil {
+				return err
+			}
+			if !hasTag(entry.Tags, tag) {
+				return fmt.Errorf("ticker %s is not tagged %s", entry.Symbol, tag)
+			}
+
+			var remaining []string
+			for _, t := range entry.Tags {
+				if !strings.EqualFold(t, tag) {
+					remaining = append(remaining, t)
+				}
+			}
+			entry.Tags = remaining
+		}
+		return nil
+	})
+}
+
+// GetTags returns the tags used in the current watchlist, sorted, along with
+// the tickers that have each tag, in manual order
+func (s *Service) GetTags() ([]string, map[string][]string, error) {
+	watchlist, err := s.config.LoadWatchlist(s.list)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tickers := make(map[string][]string)
+	for _, entry := range watchlist.Entries {
+		for _, tag := range entry.Tags {
+			tag = strings.ToLower(tag)
+			tickers[tag] = append(tickers[tag], entry.Symbol)
+		}
+	}
+
+	tags := make([]string, 0, len(tickers))
+	for tag := range tickers {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	return tags, tickers, nil
+}
+
+// GetTickerTags returns the tags of a ticker in the current watchlist
+func (s *Service) GetTickerTags(ticker string) ([]string, error) {
+	ticker = strings.TrimSpace(strings.ToUpper(ticker))
+
+	watchlist, err := s.config.LoadWatchlist(s.list)
+	if err != nil {
+		return nil, err
+	}
+
+	i := watchlist.Find(ticker)
+	if i < 0 {
+		return nil, fmt.Errorf("ticker %s is not in the watchlist", ticker)
+	}
+
+	return watchlist.Entries[i].Tags, nil
+}
+
+// modifyEntry applies fn to the entry for a ticker in the watchlist and saves the result
+func (s *Service) modifyEntry(ticker string, fn func(entry *config.WatchlistEntry) error) error {
+	// Normalize the ticker
+	ticker = strings.TrimSpace(strings.ToUpper(ticker))
+	if ticker == "" {
+		return fmt.Errorf("ticker cannot be empty")
+	}
+
+	return s.modify(func(watchlist *config.Watchlist) error {
+		i := watchlist.Find(ticker)
+		if i < 0 {
+			return fmt.Errorf("ticker %s is not in the watchlist", ticker)
+		}
+		return fn(&watchlist.Entries[i])
+	})
+}
+
+// hasTag reports whether tags contains tag. Tags are compared regardless of
+// case, since watchlist files may be edited by hand.
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
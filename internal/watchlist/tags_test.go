@@ -0,0 +1,129 @@
+package watchlist
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"stockterm/internal/config"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	tests := []struct {
+		terms []string
+		want  TagFilter
+		valid bool
+	}{
+		{nil, TagFilter{}, true},
+		{[]string{}, TagFilter{}, true},
+		{[]string{"Tech"}, TagFilter{Include: []string{"tech"}}, true},
+		{[]string{"tech", "-Crypto", " div "}, TagFilter{Include: []string{"tech", "div"}, Exclude: []string{"crypto"}}, true},
+		{[]string{""}, TagFilter{}, false},
+		{[]string{"-"}, TagFilter{}, false},
+		{[]string{"--tech"}, TagFilter{}, false},
+		{[]string{"big tech"}, TagFilter{}, false},
+		{[]string{"tech,div"}, TagFilter{}, false},
+	}
+
+	for _, tt := range tests {
+		filter, err := ParseTagFilter(tt.terms)
+		if !tt.valid {
+			if err == nil {
+				t.Errorf("ParseTagFilter(%q) = %+v, want an error", tt.terms, filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTagFilter(%q): %v", tt.terms, err)
+			continue
+		}
+		if !reflect.DeepEqual(filter, tt.want) {
+			t.Errorf("ParseTagFilter(%q) = %+v, want %+v", tt.terms, filter, tt.want)
+		}
+		if filter.IsEmpty() != (len(tt.want.Include)+len(tt.want.Exclude) == 0) {
+			t.Errorf("ParseTagFilter(%q).IsEmpty() = %t", tt.terms, filter.IsEmpty())
+		}
+	}
+}
+
+func TestTagFilterMatches(t *testing.T) {
+	tests := []struct {
+		terms []string
+		tags  []string
+		want  bool
+	}{
+		// An empty filter matches everything
+		{nil, nil, true},
+		{nil, []string{"tech"}, true},
+		{[]string{"tech"}, nil, false},
+		{[]string{"tech"}, []string{"div", "tech"}, true},
+		{[]string{"tech", "div"}, []string{"tech"}, false},
+		{[]string{"tech", "div"}, []string{"div", "tech"}, true},
+		{[]string{"-crypto"}, nil, true},
+		{[]string{"-crypto"}, []string{"crypto"}, false},
+		{[]string{"tech", "-crypto"}, []string{"tech", "crypto"}, false},
+		// Tags written by hand in another case still match
+		{[]string{"TECH"}, []string{"Tech"}, true},
+		{[]string{"-Crypto"}, []string{"CRYPTO"}, false},
+	}
+
+	for _, tt := range tests {
+		filter, err := ParseTagFilter(tt.terms)
+		if err != nil {
+			t.Fatalf("ParseTagFilter(%q): %v", tt.terms, err)
+		}
+		if got := filter.Matches(tt.tags); got != tt.want {
+			t.Errorf("filter %q matches %q = %t, want %t", tt.terms, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestTagsWrittenByHand(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SetDataDir(t.TempDir())
+	if err := os.MkdirAll(cfg.WatchlistDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "version: 1\nentries:\n  - symbol: AAPL\n    tags: [Tech]\n  - symbol: BTC-USD\n    tags: [Crypto, TECH]\n"
+	if err := os.WriteFile(filepath.Join(cfg.WatchlistDir, "default.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	service := NewService(cfg)
+
+	filter, err := ParseTagFilter([]string{"tech", "-crypto"})
+	if err != nil {
+		t.Fatalf("ParseTagFilter: %v", err)
+	}
+	tickers, err := service.GetTaggedWatchlist(filter)
+	if err != nil {
+		t.Fatalf("GetTaggedWatchlist: %v", err)
+	}
+	if want := []string{"AAPL"}; !reflect.DeepEqual(tickers, want) {
+		t.Errorf("tagged = %q, want %q", tickers, want)
+	}
+
+	tags, tagged, err := service.GetTags()
+	if err != nil {
+		t.Fatalf("GetTags: %v", err)
+	}
+	if want := []string{"crypto", "tech"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %q, want %q", tags, want)
+	}
+	if want := []string{"AAPL", "BTC-USD"}; !reflect.DeepEqual(tagged["tech"], want) {
+		t.Errorf("tagged tech = %q, want %q", tagged["tech"], want)
+	}
+
+	// Adding a tag in another case doesn't duplicate it, and removing it works
+	if err := service.AddTags("AAPL", []string{"tech"}); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	if err := service.RemoveTags("BTC-USD", []string{"crypto"}); err != nil {
+		t.Fatalf("RemoveTags: %v", err)
+	}
+	for ticker, want := range map[string][]string{"AAPL": {"Tech"}, "BTC-USD": {"TECH"}} {
+		if tags, err := service.GetTickerTags(ticker); err != nil || !reflect.DeepEqual(tags, want) {
+			t.Errorf("%s tags = %q, %v, want %q", ticker, tags, err, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"stockterm/internal/api"
+	"stockterm/internal/config"
+	"stockterm/internal/model"
+	"stockterm/internal/symbol"
+)
+
+// maxSuggestions is the number of close matches suggested for an unknown ticker
+const maxSuggestions = 3
+
+// UnknownTickerError is returned by Verify for tickers the provider doesn't know
+type UnknownTickerError struct {
+	// Ticker is the unknown ticker
+	Ticker string
+	// Suggestions are known tickers close to Ticker, closest first
+	Suggestions []string
+}
+
+// Error implements the error interface
+func (e *UnknownTickerError) Error() string {
+	msg := fmt.Sprintf("ticker %s not found", e.Ticker)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(", did you mean %s?", strings.Join(e.Suggestions, ", "))
+	}
+	return msg
+}
+
+// Unwrap returns api.ErrNotFound
+func (e *UnknownTickerError) Unwrap() error {
+	return api.ErrNotFound
+}
+
+// Verify checks that the provider knows a ticker, and returns its entry with
+// the canonical symbol and the exchange reported by the provider. Unknown
+// tickers are reported as an *UnknownTickerError suggesting close matches.
+func Verify(ctx context.Context, provider api.Provider, ticker string) (config.WatchlistEntry, error) {
+	ticker, err := symbol.Normalize(ticker)
+	if err != nil {
+		return config.WatchlistEntry{}, err
+	}
+
+	response, err := provider.FetchQuote(ctx, ticker)
+	if errors.Is(err, api.ErrNotFound) {
+		// Suggestions are a nicety, so failing to find any is not an error
+		results, _ := provider.Search(ctx, ticker)
+		return config.WatchlistEntry{}, &UnknownTickerError{Ticker: ticker, Suggestions: suggest(ticker, results)}
+	}
+	if err != nil {
+		return config.WatchlistEntry{}, err
+	}
+
+	meta := response.Chart.Result[0].Meta
+	entry := config.WatchlistEntry{
+		Symbol:   strings.ToUpper(meta.Symbol),
+		Exchange: meta.FullExchangeName,
+	}
+	if entry.Symbol == "" {
+		entry.Symbol = ticker
+	}
+	if entry.Exchange == "" {
+		entry.Exchange = meta.ExchangeName
+	}
+
+	return entry, nil
+}
+
+// suggest returns the symbols among search results that are closest to ticker
+func suggest(ticker string, results []model.SearchResult) []string {
+	type candidate struct {
+		symbol   string
+		distance int
+	}
+
+	var candidates []candidate
+	seen := make(map[string]bool)
+	for _, result := range results {
+		sym := strings.ToUpper(result.Symbol)
+		if sym == "" || sym == ticker || seen[sym] {
+			continue
+		}
+		seen[sym] = true
+		candidates = append(candidates, candidate{sym, editDistance(ticker, sym)})
+	}
+
+	// Keep the search's relevance order among equally close symbols
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].symbol)
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
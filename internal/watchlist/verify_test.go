@@ -0,0 +1,173 @@
+package watchlist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"stockterm/internal/api"
+	"stockterm/internal/model"
+)
+
+// stubProvider knows the tickers in quotes, returns searchResults for every
+// search, or searchErr if it is set, and records the tickers it is asked for
+type stubProvider struct {
+	api.Provider
+	quotes        map[string]string
+	quoteErr      error
+	searchResults []string
+	searchErr     error
+	asked         []string
+}
+
+// FetchQuote returns a chart with the stubbed exchange for known tickers
+func (s *stubProvider) FetchQuote(ctx context.Context, ticker string) (model.ChartResponse, error) {
+	s.asked = append(s.asked, ticker)
+
+	var response model.ChartResponse
+	if s.quoteErr != nil {
+		return response, s.quoteErr
+	}
+	exchange, ok := s.quotes[ticker]
+	if !ok {
+		return response, &api.FetchError{Ticker: ticker, Kind: api.ErrNotFound}
+	}
+
+	body := fmt.Sprintf(`{"chart":{"result":[{"meta":{"symbol":%q,"exchangeName":"EXC","fullExchangeName":%q}}]}}`, strings.ToLower(ticker), exchange)
+	err := json.Unmarshal([]byte(body), &response)
+	return response, err
+}
+
+// Search returns the stubbed search results
+func (s *stubProvider) Search(ctx context.Context, query string) ([]model.SearchResult, error) {
+	if s.searchErr != nil {
+		return nil, s.searchErr
+	}
+	results := make([]model.SearchResult, len(s.searchResults))
+	for i, sym := range s.searchResults {
+		results[i] = model.SearchResult{Symbol: sym}
+	}
+	return results, nil
+}
+
+func TestVerifyKnownTicker(t *testing.T) {
+	provider := &stubProvider{quotes: map[string]string{"BRK-B": "NYSE", "SAP.DE": ""}}
+
+	tests := []struct {
+		ticker   string
+		symbol   string
+		exchange string
+	}{
+		{"brk.b", "BRK-B", "NYSE"},
+		// The short exchange name is used when there is no full one
+		{"SAP GY", "SAP.DE", "EXC"},
+	}
+
+	for _, tt := range tests {
+		entry, err := Verify(context.Background(), provider, tt.ticker)
+		if err != nil {
+			t.Errorf("Verify(%q): %v", tt.ticker, err)
+			continue
+		}
+		if entry.Symbol != tt.symbol || entry.Exchange != tt.exchange {
+			t.Errorf("Verify(%q) = %s on %s, want %s on %s", tt.ticker, entry.Symbol, entry.Exchange, tt.symbol, tt.exchange)
+		}
+	}
+}
+
+func TestVerifyUnknownTicker(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    *stubProvider
+		suggestions []string
+	}{
+		{
+			name: "near miss",
+			provider: &stubProvider{
+				searchResults: []string{"XYZW", "AAPL", "APPL", "aapn", "APLE", "APPN", "APP"},
+			},
+			// The closest symbols first, in search order when equally close,
+			// leaving out the ticker itself and duplicates
+			suggestions: []string{"AAPL", "APPN", "APP"},
+		},
+		{
+			name:     "no results",
+			provider: &stubProvider{},
+		},
+		{
+			name:     "search fails",
+			provider: &stubProvider{searchErr: api.ErrNetwork, searchResults: []string{"AAPL"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Verify(context.Background(), tt.provider, "APPL")
+
+			var unknown *UnknownTickerError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("err = %v, want an UnknownTickerError", err)
+			}
+			if !errors.Is(err, api.ErrNotFound) {
+				t.Errorf("err = %v, want it to match api.ErrNotFound", err)
+			}
+			if unknown.Ticker != "APPL" || !reflect.DeepEqual(unknown.Suggestions, tt.suggestions) {
+				t.Errorf("unknown = %s with suggestions %q, want APPL with %q", unknown.Ticker, unknown.Suggestions, tt.suggestions)
+			}
+			if suggested := strings.Contains(err.Error(), "did you mean"); suggested != (len(tt.suggestions) > 0) {
+				t.Errorf("message = %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestVerifyOtherErrors(t *testing.T) {
+	provider := &stubProvider{quoteErr: &api.FetchError{Ticker: "AAPL", Kind: api.ErrNetwork}}
+
+	_, err := Verify(context.Background(), provider, "AAPL")
+	var unknown *UnknownTickerError
+	if !errors.Is(err, api.ErrNetwork) || errors.As(err, &unknown) {
+		t.Errorf("err = %v, want the network error", err)
+	}
+
+	// Invalid tickers are rejected without asking the provider
+	provider = &stubProvider{}
+	if _, err := Verify(context.Background(), provider, "  "); err == nil {
+		t.Error("Verify of an empty ticker succeeded")
+	}
+	if len(provider.asked) > 0 {
+		t.Errorf("asked the provider for %q", provider.asked)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"AAPL", "", 4},
+		{"", "MSFT", 4},
+		{"AAPL", "AAPL", 0},
+		{"APPL", "AAPL", 1},
+		{"APPL", "AAPN", 2},
+		{"APPL", "APP", 1},
+		{"MSFT", "MSFO", 1},
+		{"GOOG", "GOOGL", 1},
+		{"TSLA", "ATSL", 2},
+		{"NVDA", "AMD", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -2,15 +2,17 @@ package watchlist
 
 import (
 	"fmt"
-	"sort"
 	"strings"
+	"time"
 
 	"stockterm/internal/config"
+	"stockterm/internal/symbol"
 )
 
 // Service provides operations for managing the watchlist
 type Service struct {
 	config *config.Config
+	list   string
 }
 
 // NewService creates a new watchlist service
@@ -20,44 +22,87 @@ func NewService(cfg *config.Config) *Service {
 	}
 }
 
-// GetWatchlist returns the current watchlist
+// WithList sets the name of the watchlist the service operates on. An empty
+// name, the default, means the watchlist in use.
+func (s *Service) WithList(name string) *Service {
+	s.list = name
+	return s
+}
+
+// GetWatchlist returns the tickers in the current watchlist. Tickers are
+// sorted for alphabetical order, and in manual order otherwise; orders that
+// depend on quotes are applied to fetched results with OrderResults.
 func (s *Service) GetWatchlist() ([]string, error) {
-	return s.config.LoadWatchlist()
+	return s.GetTaggedWatchlist(TagFilter{})
 }
 
-// AddTicker adds a ticker to the watchlist
-func (s *Service) AddTicker(ticker string) error {
-	// Normalize the ticker
-	ticker = strings.TrimSpace(strings.ToUpper(ticker))
-	if ticker == "" {
-		return fmt.Errorf("ticker cannot be empty")
+// Lists returns the names of all watchlists and the name of the one in use
+func (s *Service) Lists() ([]string, string, error) {
+	names, err := s.config.Watchlists()
+	if err != nil {
+		return nil, "", err
 	}
 
-	// Get the current watchlist
-	watchlist, err := s.config.LoadWatchlist()
+	current, err := s.config.CurrentWatchlist()
 	if err != nil {
-		return fmt.Errorf("failed to load watchlist: %w", err)
+		return nil, "", err
 	}
 
-	// Check if the ticker is already in the watchlist
-	for _, t := range watchlist {
-		if t == ticker {
-			return fmt.Errorf("ticker %s is already in the watchlist", ticker)
-		}
-	}
+	return names, current, nil
+}
+
+// CreateList creates an empty watchlist
+func (s *Service) CreateList(name string) error {
+	return s.config.CreateWatchlist(name)
+}
 
-	// Add the ticker to the watchlist
-	watchlist = append(watchlist, ticker)
+// DeleteList deletes a watchlist
+func (s *Service) DeleteList(name string) error {
+	return s.config.DeleteWatchlist(name)
+}
 
-	// Sort the watchlist
-	sort.Strings(watchlist)
+// RenameList renames a watchlist
+func (s *Service) RenameList(oldName, newName string) error {
+	return s.config.RenameWatchlist(oldName, newName)
+}
 
-	// Save the updated watchlist
-	if err := s.config.SaveWatchlist(watchlist); err != nil {
-		return fmt.Errorf("failed to save watchlist: %w", err)
+// UseList selects the watchlist used when no name is given
+func (s *Service) UseList(name string) error {
+	return s.config.UseWatchlist(name)
+}
+
+// AddTicker adds a ticker to the watchlist
+func (s *Service) AddTicker(ticker string) error {
+	return s.AddEntry(config.WatchlistEntry{Symbol: ticker})
+}
+
+// AddEntry adds a ticker to the watchlist along with its metadata. The time
+// it was added is set to now if it is zero.
+func (s *Service) AddEntry(entry config.WatchlistEntry) error {
+	// Normalize the ticker
+	sym, err := symbol.Normalize(entry.Symbol)
+	if err != nil {
+		return err
+	}
+	entry.Symbol = sym
+	if entry.AddedAt.IsZero() {
+		entry.AddedAt = time.Now().UTC().Truncate(time.Second)
 	}
 
-	return nil
+	return s.modify(func(watchlist *config.Watchlist) error {
+		// Check if the ticker is already in the watchlist, in any notation
+		if i := watchlist.Find(entry.Symbol); i >= 0 {
+			if existing := watchlist.Entries[i].Symbol; existing != entry.Symbol {
+				return fmt.Errorf("ticker %s is already in the watchlist as %s", entry.Symbol, existing)
+			}
+			return fmt.Errorf("ticker %s is already in the watchlist", entry.Symbol)
+		}
+
+		// Add the ticker to the end of the watchlist
+		watchlist.Entries = append(watchlist.Entries, entry)
+
+		return nil
+	})
 }
 
 // RemoveTicker removes a ticker from the watchlist
@@ -68,33 +113,16 @@ func (s *Service) RemoveTicker(ticker string) error {
 		return fmt.Errorf("ticker cannot be empty")
 	}
 
-	// Get the current watchlist
-	watchlist, err := s.config.LoadWatchlist()
-	if err != nil {
-		return fmt.Errorf("failed to load watchlist: %w", err)
-	}
-
-	// Check if the ticker is in the watchlist
-	found := false
-	var newWatchlist []string
-	for _, t := range watchlist {
-		if t != ticker {
-			newWatchlist = append(newWatchlist, t)
-		} else {
-			found = true
+	return s.modify(func(watchlist *config.Watchlist) error {
+		// Check if the ticker is in the watchlist
+		i := watchlist.Find(ticker)
+		if i < 0 {
+			return fmt.Errorf("ticker %s is not in the watchlist", ticker)
 		}
-	}
 
-	if !found {
-		return fmt.Errorf("ticker %s is not in the watchlist", ticker)
-	}
-
-	// Save the updated watchlist
-	if err := s.config.SaveWatchlist(newWatchlist); err != nil {
-		return fmt.Errorf("failed to save watchlist: %w", err)
-	}
-
-	return nil
+		watchlist.Entries = append(watchlist.Entries[:i], watchlist.Entries[i+1:]...)
+		return nil
+	})
 }
 
 // AddMultipleTickers adds multiple tickers to the watchlist
@@ -115,20 +143,116 @@ func (s *Service) RemoveMultipleTickers(tickers []string) error {
 	return nil
 }
 
-// UpdateWatchlist replaces the entire watchlist with a new one
+// UpdateWatchlist replaces the entire watchlist with a new one, in the given
+// manual order. Tickers that were already in the watchlist keep their
+// metadata. If tickers were reordered, the watchlist switches to manual order.
 func (s *Service) UpdateWatchlist(tickers []string) error {
-	// Normalize and sort the tickers
-	var normalizedTickers []string
-	for _, ticker := range tickers {
-		normalized := strings.TrimSpace(strings.ToUpper(ticker))
-		if normalized != "" {
-			normalizedTickers = append(normalizedTickers, normalized)
+	return s.modify(func(watchlist *config.Watchlist) error {
+		now := time.Now().UTC().Truncate(time.Second)
+
+		// Normalize the tickers, looking up their existing entries
+		var entries []config.WatchlistEntry
+		reordered := false
+		last := -1
+		for _, ticker := range tickers {
+			normalized := strings.TrimSpace(strings.ToUpper(ticker))
+			if normalized == "" {
+				continue
+			}
+
+			i := watchlist.Find(normalized)
+			if i < 0 {
+				entries = append(entries, config.WatchlistEntry{Symbol: normalized, AddedAt: now})
+				continue
+			}
+
+			entries = append(entries, watchlist.Entries[i])
+			if i < last {
+				reordered = true
+			}
+			last = i
 		}
+
+		// Replace the watchlist
+		watchlist.Entries = entries
+		if reordered {
+			watchlist.Order = config.OrderManual
+		}
+		return nil
+	})
+}
+
+// GetManualOrder returns the tickers in the current watchlist in manual
+// order, regardless of the watchlist's order
+func (s *Service) GetManualOrder() ([]string, error) {
+	watchlist, err := s.config.LoadWatchlist(s.list)
+	if err != nil {
+		return nil, err
+	}
+	return watchlist.Symbols(), nil
+}
+
+// GetOrder returns the order in which the current watchlist is displayed
+func (s *Service) GetOrder() (config.WatchlistOrder, error) {
+	watchlist, err := s.loadOrdered()
+	if err != nil {
+		return "", err
+	}
+	if watchlist.Order == "" {
+		return config.OrderManual, nil
+	}
+	return watchlist.Order, nil
+}
+
+// SetOrder sets the order in which the current watchlist is displayed. The
+// manual order is kept, so switching back to it restores the curated order.
+func (s *Service) SetOrder(order config.WatchlistOrder) error {
+	return s.modify(func(watchlist *config.Watchlist) error {
+		watchlist.Order = order
+		return nil
+	})
+}
+
+// loadOrdered loads the watchlist to display it in its order, which fails if
+// the order is no longer supported. Modifications don't check the order, so
+// that it can be changed.
+func (s *Service) loadOrdered() (*config.Watchlist, error) {
+	watchlist, err := s.config.LoadWatchlist(s.list)
+	if err != nil {
+		return nil, err
+	}
+	if err := watchlist.CheckOrder(); err != nil {
+		return nil, err
+	}
+	return watchlist, nil
+}
+
+// modify loads the watchlist, applies fn to it and saves the result, holding
+// the watchlist lock throughout so that concurrent modifications aren't lost.
+// Nothing is saved if fn returns an error.
+func (s *Service) modify(fn func(watchlist *config.Watchlist) error) (err error) {
+	lock, err := s.config.LockWatchlist(s.list)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := lock.Unlock(); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to unlock watchlist: %w", unlockErr)
+		}
+	}()
+
+	// Get the current watchlist
+	watchlist, err := s.config.LoadWatchlist(s.list)
+	if err != nil {
+		return fmt.Errorf("failed to load watchlist: %w", err)
+	}
+
+	if err := fn(watchlist); err != nil {
+		return err
 	}
-	sort.Strings(normalizedTickers)
 
 	// Save the updated watchlist
-	if err := s.config.SaveWatchlist(normalizedTickers); err != nil {
+	if err := s.config.SaveWatchlist(s.list, watchlist); err != nil {
 		return fmt.Errorf("failed to save watchlist: %w", err)
 	}
 
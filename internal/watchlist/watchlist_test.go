@@ -0,0 +1,88 @@
+package watchlist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"stockterm/internal/config"
+)
+
+func TestAddTickerConcurrently(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SetDataDir(t.TempDir())
+
+	const n = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each goroutine uses a service of its own, like separate processes
+			errs <- NewService(cfg).AddTicker(fmt.Sprintf("T%d", i))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("AddTicker: %v", err)
+		}
+	}
+
+	watchlist, err := cfg.LoadWatchlist("")
+	if err != nil {
+		t.Fatalf("LoadWatchlist: %v", err)
+	}
+	if got := len(watchlist.Entries); got != n {
+		t.Fatalf("watchlist has %d entries, want %d", got, n)
+	}
+	for i := 0; i < n; i++ {
+		if ticker := fmt.Sprintf("T%d", i); watchlist.Find(ticker) < 0 {
+			t.Errorf("ticker %s is missing from the watchlist", ticker)
+		}
+	}
+}
+
+func TestUnsupportedOrder(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SetDataDir(t.TempDir())
+	if err := os.MkdirAll(cfg.WatchlistDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "version: 1\norder: market-cap\nentries:\n  - symbol: AAPL\n"
+	if err := os.WriteFile(filepath.Join(cfg.WatchlistDir, "default.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService(cfg)
+
+	// The watchlist can't be displayed in the order it was saved with
+	if _, err := service.GetWatchlist(); err == nil || !strings.Contains(err.Error(), "market-cap") {
+		t.Errorf("GetWatchlist: err = %v, want an error naming the order", err)
+	}
+	if _, err := service.GetOrder(); err == nil {
+		t.Error("GetOrder succeeded, want an error")
+	}
+
+	// It can still be modified, and its order changed
+	if err := service.AddTicker("MSFT"); err != nil {
+		t.Fatalf("AddTicker: %v", err)
+	}
+	if err := service.SetOrder(config.OrderAlphabetical); err != nil {
+		t.Fatalf("SetOrder: %v", err)
+	}
+	tickers, err := service.GetWatchlist()
+	if err != nil {
+		t.Fatalf("GetWatchlist: %v", err)
+	}
+	if strings.Join(tickers, ",") != "AAPL,MSFT" {
+		t.Errorf("tickers = %v, want AAPL,MSFT", tickers)
+	}
+}